@@ -0,0 +1,82 @@
+package cloudevents
+
+import (
+	"context"
+	"fmt"
+
+	ce "github.com/cloudevents/sdk-go/v2"
+	"github.com/ImpactInsights/valuestream/eventsources"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Sink publishes a finished CloudEvent somewhere outside the process:
+// an HTTP endpoint, a Kafka topic, whatever the consuming platform
+// expects.
+type Sink interface {
+	Send(ctx context.Context, event ce.Event) error
+}
+
+// Emitter republishes a Webhook's span lifecycle (start, end, error) as
+// CloudEvents, satisfying webhooks.SpanLifecycleEmitter. Source is used
+// as the CloudEvent's `source` attribute for every event this Emitter
+// produces (typically something like "valuestream/<deployment>").
+type Emitter struct {
+	Source string
+	Sink   Sink
+}
+
+// NewEmitter returns an Emitter publishing to sink, stamping every
+// CloudEvent's source attribute with source.
+func NewEmitter(source string, sink Sink) *Emitter {
+	return &Emitter{Source: source, Sink: sink}
+}
+
+func (em *Emitter) EmitStart(ctx context.Context, span opentracing.Span, e eventsources.Event) error {
+	return em.emit(ctx, "io.valuestream.span.start", span, e)
+}
+
+func (em *Emitter) EmitEnd(ctx context.Context, span opentracing.Span, e eventsources.Event) error {
+	isErr, err := e.IsError()
+	if err != nil {
+		return err
+	}
+
+	ceType := "io.valuestream.span.end"
+	if isErr {
+		ceType = "io.valuestream.span.error"
+	}
+
+	return em.emit(ctx, ceType, span, e)
+}
+
+func (em *Emitter) emit(ctx context.Context, ceType string, span opentracing.Span, e eventsources.Event) error {
+	spanID, err := e.SpanID()
+	if err != nil {
+		return err
+	}
+
+	tags, err := e.Tags()
+	if err != nil {
+		return err
+	}
+
+	event := ce.NewEvent()
+	event.SetID(spanID)
+	event.SetType(ceType)
+	event.SetSource(em.Source)
+	event.SetSubject(e.OperationName())
+
+	if err := event.SetData(ce.ApplicationJSON, tags); err != nil {
+		return fmt.Errorf("encoding cloudevent data: %w", err)
+	}
+
+	event.SetExtension(extensionSpanID, spanID)
+
+	if traceID, err := e.TraceID(); err == nil && traceID != nil {
+		event.SetExtension(extensionTraceID, *traceID)
+	}
+
+	injectTraceContext(span, &event)
+
+	return em.Sink.Send(ctx, event)
+}
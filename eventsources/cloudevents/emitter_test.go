@@ -0,0 +1,74 @@
+package cloudevents
+
+import (
+	"context"
+	"testing"
+
+	ce "github.com/cloudevents/sdk-go/v2"
+	"github.com/ImpactInsights/valuestream/eventsources"
+	"github.com/opentracing/opentracing-go"
+)
+
+type fakeEmitterEvent struct {
+	spanID  string
+	traceID *string
+	isError bool
+}
+
+func (e fakeEmitterEvent) OperationName() string { return "test.op" }
+func (e fakeEmitterEvent) State() (eventsources.EventState, error) {
+	return eventsources.StartState, nil
+}
+func (e fakeEmitterEvent) Tags() (map[string]string, error) {
+	return map[string]string{"service": "test"}, nil
+}
+func (e fakeEmitterEvent) SpanID() (string, error)        { return e.spanID, nil }
+func (e fakeEmitterEvent) ParentSpanID() (*string, error) { return nil, nil }
+func (e fakeEmitterEvent) TraceID() (*string, error)      { return e.traceID, nil }
+func (e fakeEmitterEvent) IsError() (bool, error)         { return e.isError, nil }
+
+type captureSink struct {
+	event ce.Event
+}
+
+func (s *captureSink) Send(ctx context.Context, event ce.Event) error {
+	s.event = event
+	return nil
+}
+
+func TestEmitterEmitStartStampsSpanAndTraceExtensions(t *testing.T) {
+	traceID := "trace-1"
+	sink := &captureSink{}
+	em := NewEmitter("valuestream/test", sink)
+
+	span := opentracing.NoopTracer{}.StartSpan("test.op")
+
+	if err := em.EmitStart(context.Background(), span, fakeEmitterEvent{spanID: "span-1", traceID: &traceID}); err != nil {
+		t.Fatalf("EmitStart: %v", err)
+	}
+
+	if sink.event.Type() != "io.valuestream.span.start" {
+		t.Fatalf("event.Type() = %q, want io.valuestream.span.start", sink.event.Type())
+	}
+	if got := sink.event.Extensions()[extensionSpanID]; got != "span-1" {
+		t.Fatalf("extensions[%q] = %v, want %q", extensionSpanID, got, "span-1")
+	}
+	if got := sink.event.Extensions()[extensionTraceID]; got != traceID {
+		t.Fatalf("extensions[%q] = %v, want %q", extensionTraceID, got, traceID)
+	}
+}
+
+func TestEmitterEmitEndUsesErrorTypeWhenEventIsError(t *testing.T) {
+	sink := &captureSink{}
+	em := NewEmitter("valuestream/test", sink)
+
+	span := opentracing.NoopTracer{}.StartSpan("test.op")
+
+	if err := em.EmitEnd(context.Background(), span, fakeEmitterEvent{spanID: "span-1", isError: true}); err != nil {
+		t.Fatalf("EmitEnd: %v", err)
+	}
+
+	if sink.event.Type() != "io.valuestream.span.error" {
+		t.Fatalf("event.Type() = %q, want io.valuestream.span.error", sink.event.Type())
+	}
+}
@@ -0,0 +1,124 @@
+package cloudevents
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+	ce "github.com/cloudevents/sdk-go/v2"
+)
+
+// extension keys Valuestream itself reads off the CloudEvent to learn
+// which span it belongs to, alongside the CloudEvents distributed
+// tracing extension handled in webhooks.ExtractSpanContext.
+const (
+	extensionSpanID       = "vsspanid"
+	extensionParentSpanID = "vsparentspanid"
+	extensionTraceID      = "vstraceid"
+	extensionError        = "vserror"
+)
+
+// Event adapts a parsed CloudEvent to eventsources.Event. source +
+// subject become service/resource tags, and the vs* extensions above
+// (set by the producer, typically another Valuestream instance via
+// Emitter, or a source that knows Valuestream's schema) carry span
+// linkage that CloudEvents has no native concept of.
+type Event struct {
+	ce            ce.Event
+	operationName string
+	state         eventsources.EventState
+}
+
+func (e *Event) OperationName() string {
+	return e.operationName
+}
+
+func (e *Event) State() (eventsources.EventState, error) {
+	return e.state, nil
+}
+
+func (e *Event) Tags() (map[string]string, error) {
+	tags := map[string]string{
+		"cloudevents.type":   e.ce.Type(),
+		"cloudevents.source": e.ce.Source(),
+		"service":            e.ce.Source(),
+	}
+
+	if subject := e.ce.Subject(); subject != "" {
+		tags["resource"] = subject
+	}
+
+	return tags, nil
+}
+
+func (e *Event) stringExtension(key string) (string, bool) {
+	v, ok := e.ce.Extensions()[key]
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// SpanID returns the vsspanid extension when present (an event emitted
+// by another Valuestream instance, or a source that deliberately knows
+// Valuestream's schema), or derives one from source+subject for a
+// genuine third-party CloudEvent (a Knative/Argo/Harbor/Jenkins
+// platform has no reason to carry a Valuestream-specific extension).
+// source+subject is the CloudEvents-native way to name "the thing this
+// event is about" (a PR number, a build ID, ...) and, unlike ce.ID()
+// (unique per delivery), stays the same across a start event and its
+// matching end event, which is what handleStartEvent/handleEndEvent
+// need to find the same span again.
+func (e *Event) SpanID() (string, error) {
+	if spanID, ok := e.stringExtension(extensionSpanID); ok {
+		return spanID, nil
+	}
+
+	if subject := e.ce.Subject(); subject != "" {
+		return derivedSpanID(e.ce.Source(), subject), nil
+	}
+
+	return "", fmt.Errorf("cloudevent %q (source %q, type %q) has neither a %q extension nor a subject to derive one from", e.ce.ID(), e.ce.Source(), e.ce.Type(), extensionSpanID)
+}
+
+// derivedSpanID deterministically derives a span ID for a CloudEvent
+// that doesn't carry Valuestream's own vsspanid extension.
+func derivedSpanID(source, subject string) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + subject))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParentSpanID returns the vsparentspanid extension, or nil if absent
+// - unlike SpanID, this has no third-party fallback: CloudEvents has
+// no standard attribute for "the parent of this", so a third-party
+// event simply gets no SpanStore-derived parent (it may still get one
+// via webhooks.ExtractSpanContext, if it carries trace headers).
+func (e *Event) ParentSpanID() (*string, error) {
+	parentID, ok := e.stringExtension(extensionParentSpanID)
+	if !ok {
+		return nil, nil
+	}
+	return &parentID, nil
+}
+
+// TraceID returns the vstraceid extension, or nil if absent. As with
+// ParentSpanID, there's no third-party fallback: CloudEvents has
+// nothing analogous to a trace root, so a third-party event without
+// this extension simply never becomes one.
+func (e *Event) TraceID() (*string, error) {
+	traceID, ok := e.stringExtension(extensionTraceID)
+	if !ok {
+		return nil, nil
+	}
+	return &traceID, nil
+}
+
+func (e *Event) IsError() (bool, error) {
+	isErr, ok := e.stringExtension(extensionError)
+	if !ok {
+		return false, nil
+	}
+	return isErr == "true", nil
+}
@@ -0,0 +1,104 @@
+package cloudevents
+
+import (
+	"testing"
+
+	ce "github.com/cloudevents/sdk-go/v2"
+)
+
+func newCE(t *testing.T, source, ceType, subject string) ce.Event {
+	t.Helper()
+	e := ce.NewEvent()
+	e.SetID("delivery-" + source + ceType + subject)
+	e.SetSource(source)
+	e.SetType(ceType)
+	if subject != "" {
+		e.SetSubject(subject)
+	}
+	return e
+}
+
+func TestEventSpanIDPrefersVsspanidExtension(t *testing.T) {
+	raw := newCE(t, "https://github.com/acme/repo", "com.github.pull_request.opened", "42")
+	raw.SetExtension(extensionSpanID, "explicit-span-id")
+
+	got, err := (&Event{ce: raw}).SpanID()
+	if err != nil {
+		t.Fatalf("SpanID: %v", err)
+	}
+	if got != "explicit-span-id" {
+		t.Fatalf("SpanID() = %q, want the vsspanid extension value", got)
+	}
+}
+
+// TestEventSpanIDDerivedIsStableAcrossLifecycle pins the property
+// handleStartEvent/handleEndEvent depend on: a third-party CloudEvent's
+// derived SpanID must be the same for the start and end of one logical
+// operation, even though ce.ID() (the delivery ID) differs between them.
+func TestEventSpanIDDerivedIsStableAcrossLifecycle(t *testing.T) {
+	opened := newCE(t, "https://github.com/acme/repo", "com.github.pull_request.opened", "42")
+	closed := newCE(t, "https://github.com/acme/repo", "com.github.pull_request.closed", "42")
+
+	openedSpanID, err := (&Event{ce: opened}).SpanID()
+	if err != nil {
+		t.Fatalf("SpanID(opened): %v", err)
+	}
+	closedSpanID, err := (&Event{ce: closed}).SpanID()
+	if err != nil {
+		t.Fatalf("SpanID(closed): %v", err)
+	}
+
+	if openedSpanID != closedSpanID {
+		t.Fatalf("derived SpanIDs differ across one PR's lifecycle: opened=%q closed=%q", openedSpanID, closedSpanID)
+	}
+	if openedSpanID == "" {
+		t.Fatalf("derived SpanID is empty")
+	}
+}
+
+func TestEventSpanIDDerivedDiffersAcrossSubjects(t *testing.T) {
+	pr42 := newCE(t, "https://github.com/acme/repo", "com.github.pull_request.opened", "42")
+	pr43 := newCE(t, "https://github.com/acme/repo", "com.github.pull_request.opened", "43")
+
+	spanID42, err := (&Event{ce: pr42}).SpanID()
+	if err != nil {
+		t.Fatalf("SpanID(42): %v", err)
+	}
+	spanID43, err := (&Event{ce: pr43}).SpanID()
+	if err != nil {
+		t.Fatalf("SpanID(43): %v", err)
+	}
+
+	if spanID42 == spanID43 {
+		t.Fatalf("derived SpanIDs collided for different subjects")
+	}
+}
+
+func TestEventSpanIDErrorsWithoutExtensionOrSubject(t *testing.T) {
+	raw := newCE(t, "https://github.com/acme/repo", "com.github.pull_request.opened", "")
+
+	if _, err := (&Event{ce: raw}).SpanID(); err == nil {
+		t.Fatalf("SpanID() = nil error, want an error when neither vsspanid nor subject is set")
+	}
+}
+
+func TestEventParentSpanIDAndTraceIDNilWhenAbsent(t *testing.T) {
+	raw := newCE(t, "https://github.com/acme/repo", "com.github.pull_request.opened", "42")
+	e := &Event{ce: raw}
+
+	parentSpanID, err := e.ParentSpanID()
+	if err != nil {
+		t.Fatalf("ParentSpanID: %v", err)
+	}
+	if parentSpanID != nil {
+		t.Fatalf("ParentSpanID() = %q, want nil for a third-party event", *parentSpanID)
+	}
+
+	traceID, err := e.TraceID()
+	if err != nil {
+		t.Fatalf("TraceID: %v", err)
+	}
+	if traceID != nil {
+		t.Fatalf("TraceID() = %q, want nil for a third-party event", *traceID)
+	}
+}
@@ -0,0 +1,62 @@
+package cloudevents
+
+import (
+	"context"
+	"fmt"
+
+	ce "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+// HTTPSink publishes CloudEvents to a single endpoint (e.g. a Knative
+// Broker's ingress, an Argo Events EventSource) in structured content
+// mode.
+type HTTPSink struct {
+	client ce.Client
+}
+
+// NewHTTPSink returns a Sink that POSTs to target.
+func NewHTTPSink(target string) (*HTTPSink, error) {
+	p, err := cehttp.New(cehttp.WithTarget(target))
+	if err != nil {
+		return nil, fmt.Errorf("building cloudevents http protocol for %q: %w", target, err)
+	}
+
+	client, err := ce.NewClient(p)
+	if err != nil {
+		return nil, fmt.Errorf("building cloudevents http client: %w", err)
+	}
+
+	return &HTTPSink{client: client}, nil
+}
+
+func (s *HTTPSink) Send(ctx context.Context, event ce.Event) error {
+	if result := s.client.Send(ce.WithEncodingStructured(ctx), event); ce.IsUndelivered(result) {
+		return fmt.Errorf("sending cloudevent %q: %w", event.ID(), result)
+	}
+	return nil
+}
+
+// KafkaSink publishes CloudEvents onto a Kafka topic in structured
+// content mode, for platforms (Knative's KafkaChannel, Strimzi-backed
+// pipelines, ...) that prefer a log over HTTP.
+//
+// Producer is kept as a narrow single-method interface here so this
+// package doesn't force a specific Kafka client on callers that don't
+// need this sink. segmentio/kafka-go's *kafka.Writer does not satisfy
+// it as is: its method is WriteMessages, taking kafka.Message values.
+type KafkaSink struct {
+	Topic    string
+	Producer interface {
+		WriteMessage(ctx context.Context, key, value []byte) error
+	}
+}
+
+func (s *KafkaSink) Send(ctx context.Context, event ce.Event) error {
+	payload, err := event.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshalling cloudevent %q: %w", event.ID(), err)
+	}
+
+	return s.Producer.WriteMessage(ctx, []byte(event.ID()), payload)
+}
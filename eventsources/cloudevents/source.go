@@ -0,0 +1,157 @@
+// Package cloudevents lets Valuestream ingest and emit CNCF CloudEvents
+// 1.0 (https://cloudevents.io), in both the structured
+// (application/cloudevents+json) and binary (ce-* headers) content
+// modes. This makes Valuestream pluggable into any CloudEvents-native
+// platform (Knative, Argo Events, Harbor webhooks, ...) without a
+// bespoke eventsources.EventSource per tool.
+package cloudevents
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	ce "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/ImpactInsights/valuestream/eventsources"
+	"github.com/ImpactInsights/valuestream/eventsources/webhooks"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Route is the HTTP path CloudEvents sources are registered under.
+const Route = "/webhooks/cloudevents"
+
+// these map to the CloudEvents "distributed tracing" extension
+// (https://github.com/cloudevents/spec/blob/main/cloudevents/extensions/distributed-tracing.md).
+const (
+	extensionTraceparent = "traceparent"
+	extensionTracestate  = "tracestate"
+)
+
+// OperationMapper maps a CloudEvent's type (e.g.
+// "com.github.pull_request.opened", "io.jenkins.build.finished") to a
+// Valuestream operation name and whether it starts or ends a span.
+type OperationMapper func(ceType string) (name string, state eventsources.EventState, err error)
+
+// EventSource adapts an incoming CloudEvent into an eventsources.Event.
+// source + subject become the span's service/resource tags, type is
+// resolved to an operation name (and start/end state) via Map, and the
+// "traceparent"/"tracestate" extensions, when present, let
+// webhooks.ExtractSpanContext recognise the upstream trace.
+type EventSource struct {
+	Map OperationMapper
+}
+
+// New returns a CloudEvents EventSource resolving operation names with m.
+func New(m OperationMapper) *EventSource {
+	return &EventSource{Map: m}
+}
+
+// ValidatePayload reads and returns the raw request body. CloudEvents
+// sources are expected to authenticate at the transport layer (mTLS,
+// an upstream-checked bearer token, a platform-level signature such as
+// Knative's) rather than with a Valuestream-specific payload signature,
+// so candidates is accepted only for interface parity with other
+// EventSources, and the matched secret is always nil.
+func (es *EventSource) ValidatePayload(r *http.Request, candidates [][]byte) ([]byte, []byte, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading cloudevents payload: %w", err)
+	}
+	return body, nil, nil
+}
+
+// Event parses payload (and, in binary content mode, r's ce-* headers)
+// into a CloudEvent and wraps it as an eventsources.Event.
+func (es *EventSource) Event(r *http.Request, payload []byte) (eventsources.Event, error) {
+	event, err := parse(r, payload)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cloudevent: %w", err)
+	}
+
+	propagateTraceContext(event, r)
+
+	name, state, err := es.Map(event.Type())
+	if err != nil {
+		return nil, fmt.Errorf("mapping cloudevent type %q: %w", event.Type(), err)
+	}
+
+	return &Event{ce: event, operationName: name, state: state}, nil
+}
+
+// propagateTraceContext copies the CloudEvents distributed-tracing
+// extension onto r's headers, under the same "traceparent"/"tracestate"
+// names the W3C wire format uses, so the single
+// webhooks.ExtractSpanContext callsite can recognise it regardless of
+// which CloudEvents content mode the sender used. In binary mode these
+// already arrive as real HTTP headers and this is a no-op; in
+// structured mode they only exist inside the JSON body event was
+// parsed from, so without this they'd never reach ExtractSpanContext,
+// which only ever looks at r.Header.
+func propagateTraceContext(event ce.Event, r *http.Request) {
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+
+	if tp, ok := event.Extensions()[extensionTraceparent].(string); ok && tp != "" {
+		r.Header.Set(extensionTraceparent, tp)
+	}
+
+	if ts, ok := event.Extensions()[extensionTracestate].(string); ok && ts != "" {
+		r.Header.Set(extensionTracestate, ts)
+	}
+}
+
+// injectTraceContext is propagateTraceContext's outbound counterpart,
+// called from Emitter when republishing a span's lifecycle as a
+// CloudEvent (see Emitter.emit): it copies whatever headers span's own
+// Tracer would set on an outbound HTTP request (via
+// webhooks.InjectSpanContext) onto event's extensions, so the trace
+// survives a hop through a Sink that isn't itself HTTP (e.g. KafkaSink)
+// just as well as one that is, and regardless of which propagator
+// format (a tracer's own native one, B3, W3C traceparent/tracestate)
+// the configured Tracer uses.
+func injectTraceContext(span opentracing.Span, event *ce.Event) {
+	req := &http.Request{Header: make(http.Header)}
+	if err := webhooks.InjectSpanContext(span.Tracer(), span, req); err != nil {
+		return
+	}
+
+	for key := range req.Header {
+		if name := traceExtensionName(key); name != "" {
+			event.SetExtension(name, req.Header.Get(key))
+		}
+	}
+}
+
+// traceExtensionName lowercases an HTTP header name into a valid
+// CloudEvents extension attribute name - restricted by the spec to
+// lowercase letters and digits - dropping anything else (notably the
+// hyphens in e.g. "X-B3-TraceId").
+func traceExtensionName(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// parse decodes a CloudEvent from r's headers and payload. binding.ToEvent
+// auto-detects whichever content mode the sender used: binary (ce-*
+// headers, a raw data payload) or structured
+// (application/cloudevents+json).
+func parse(r *http.Request, payload []byte) (ce.Event, error) {
+	msg := cehttp.NewMessage(r.Header, ioutil.NopCloser(bytes.NewReader(payload)))
+
+	event, err := binding.ToEvent(r.Context(), msg)
+	if err != nil {
+		return ce.Event{}, err
+	}
+
+	return *event, nil
+}
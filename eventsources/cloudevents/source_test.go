@@ -0,0 +1,123 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ce "github.com/cloudevents/sdk-go/v2"
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+func echoMapper(ceType string) (string, eventsources.EventState, error) {
+	return ceType, eventsources.StartState, nil
+}
+
+func TestEventSourceEventParsesBinaryContentMode(t *testing.T) {
+	body := []byte(`{"number":42}`)
+	r := httptest.NewRequest(http.MethodPost, Route, bytes.NewReader(body))
+	r.Header.Set("Ce-Specversion", "1.0")
+	r.Header.Set("Ce-Id", "delivery-1")
+	r.Header.Set("Ce-Source", "https://github.com/acme/repo")
+	r.Header.Set("Ce-Type", "com.github.pull_request.opened")
+	r.Header.Set("Ce-Subject", "42")
+	r.Header.Set("Content-Type", "application/json")
+
+	es := New(echoMapper)
+	evt, err := es.Event(r, body)
+	if err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+
+	if evt.OperationName() != "com.github.pull_request.opened" {
+		t.Fatalf("OperationName() = %q, want the cloudevent type", evt.OperationName())
+	}
+
+	tags, err := evt.Tags()
+	if err != nil {
+		t.Fatalf("Tags: %v", err)
+	}
+	if tags["resource"] != "42" {
+		t.Fatalf("Tags()[\"resource\"] = %q, want the Ce-Subject value", tags["resource"])
+	}
+}
+
+func TestEventSourceEventParsesStructuredContentMode(t *testing.T) {
+	raw := ce.NewEvent()
+	raw.SetID("delivery-1")
+	raw.SetSource("https://github.com/acme/repo")
+	raw.SetType("com.github.pull_request.opened")
+	raw.SetSubject("42")
+	if err := raw.SetData(ce.ApplicationJSON, map[string]string{"foo": "bar"}); err != nil {
+		t.Fatalf("SetData: %v", err)
+	}
+
+	body, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, Route, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/cloudevents+json")
+
+	es := New(echoMapper)
+	evt, err := es.Event(r, body)
+	if err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+
+	if evt.OperationName() != "com.github.pull_request.opened" {
+		t.Fatalf("OperationName() = %q, want the cloudevent type", evt.OperationName())
+	}
+}
+
+// TestEventSourceEventPropagatesStructuredTraceExtensions pins the bug
+// propagateTraceContext exists to fix: in structured content mode the
+// CloudEvents distributed-tracing extension only lives inside the JSON
+// body, never as a real HTTP header, so without this copy webhooks.
+// ExtractSpanContext (which only ever looks at r.Header) would never
+// see it.
+func TestEventSourceEventPropagatesStructuredTraceExtensions(t *testing.T) {
+	raw := ce.NewEvent()
+	raw.SetID("delivery-1")
+	raw.SetSource("https://github.com/acme/repo")
+	raw.SetType("com.github.pull_request.opened")
+	raw.SetExtension(extensionTraceparent, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	raw.SetExtension(extensionTracestate, "congo=t61rcWkgMzE")
+
+	body, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, Route, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/cloudevents+json")
+
+	es := New(echoMapper)
+	if _, err := es.Event(r, body); err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+
+	if got := r.Header.Get(extensionTraceparent); got != "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01" {
+		t.Fatalf("r.Header[%q] = %q, want the traceparent carried in the structured body", extensionTraceparent, got)
+	}
+	if got := r.Header.Get(extensionTracestate); got != "congo=t61rcWkgMzE" {
+		t.Fatalf("r.Header[%q] = %q, want the tracestate carried in the structured body", extensionTracestate, got)
+	}
+}
+
+func TestTraceExtensionNameStripsInvalidCharsAndLowercases(t *testing.T) {
+	cases := map[string]string{
+		"X-B3-TraceId": "xb3traceid",
+		"traceparent":  "traceparent",
+		"Ce-Vsspanid":  "cevsspanid",
+	}
+
+	for header, want := range cases {
+		if got := traceExtensionName(header); got != want {
+			t.Errorf("traceExtensionName(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package webhooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// auditHash fingerprints a matched secret for logging: enough to tell,
+// across log lines, whether the same secret keeps matching or a
+// rotation has taken effect, without ever writing the secret itself to
+// a log.
+func auditHash(sk []byte) string {
+	if sk == nil {
+		return ""
+	}
+	sum := sha256.Sum256(sk)
+	return hex.EncodeToString(sum[:8])
+}
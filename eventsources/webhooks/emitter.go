@@ -0,0 +1,18 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+	"github.com/opentracing/opentracing-go"
+)
+
+// SpanLifecycleEmitter is notified whenever a Webhook starts or ends a
+// span, so the lifecycle can be republished in another wire format
+// (e.g. a CloudEvent, see eventsources/cloudevents.Emitter) for
+// platforms that want to consume Valuestream's output rather than only
+// feed it.
+type SpanLifecycleEmitter interface {
+	EmitStart(ctx context.Context, span opentracing.Span, e eventsources.Event) error
+	EmitEnd(ctx context.Context, span opentracing.Span, e eventsources.Event) error
+}
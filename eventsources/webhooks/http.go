@@ -1,20 +1,32 @@
 package webhooks
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/ImpactInsights/valuestream/eventsources"
+	"github.com/ImpactInsights/valuestream/queue"
+	"github.com/ImpactInsights/valuestream/sampling"
+	"github.com/ImpactInsights/valuestream/secrets"
 	"github.com/ImpactInsights/valuestream/traces"
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"time"
 )
 
 const (
 	SignatureHeader = "X-VS-Signature"
 )
 
+// Tracers vends a request-scoped tracer for an incoming webhook. The
+// returned tracer is expected to already have its inbound/outbound
+// propagators registered (e.g. via jaegercfg.Propagators or the
+// zipkin-go-opentracing B3 propagator) so that ExtractSpanContext below
+// can recognise whichever wire format (B3, W3C traceparent, ...) the
+// caller used.
 type Tracers interface {
 	RequestScoped(r *http.Request, es eventsources.EventSource) (opentracing.Tracer, io.Closer, error)
 }
@@ -22,101 +34,181 @@ type Tracers interface {
 func New(
 	es eventsources.EventSource,
 	tracers Tracers,
-	sk []byte,
+	sr secrets.Resolver,
 	ts traces.SpanStore,
 	spans traces.SpanStore,
+	eq queue.EventQueue,
 ) (*Webhook, error) {
 
 	return &Webhook{
 		EventSource: es,
 		Tracers:     tracers,
-		SecretKey:   sk,
+		Secrets:     sr,
 		Traces:      ts,
 		Spans:       spans,
+		Queue:       eq,
 	}, nil
 }
 
 type Webhook struct {
 	EventSource eventsources.EventSource
 	Tracers     Tracers
-	SecretKey   []byte
-	Traces      traces.SpanStore
-	Spans       traces.SpanStore
+
+	// Secrets resolves the signing secret(s) currently valid for an
+	// incoming request, so one instance can host many tenants (GitHub
+	// orgs, Jira/GitLab projects, ...) each rotating their own secret
+	// independently and without a restart.
+	Secrets secrets.Resolver
+
+	Traces traces.SpanStore
+	Spans  traces.SpanStore
+
+	// Queue durably holds validated-but-not-yet-processed events
+	// between the receiver (Handler) and a WorkerPool. This is what
+	// lets Handler 202 a delivery even while the SpanStore backing
+	// Traces/Spans is briefly unavailable.
+	Queue queue.EventQueue
+
+	// Emitter, if set, is notified as spans start/end so their
+	// lifecycle can be republished elsewhere (e.g. as CloudEvents).
+	Emitter SpanLifecycleEmitter
+
+	// Sampling, if set, is consulted before a start event gets a real
+	// span. A dropped event still gets a lightweight marker (see
+	// markSampledOut) so its matching end event doesn't fail with
+	// traces.SpanMissingError.
+	Sampling sampling.Policy
+}
+
+// sourceLabel is a low-cardinality identifier for wh.EventSource,
+// suitable as a Prometheus label / rate-limiter key, without requiring
+// every EventSource implementation to expose its own name.
+func sourceLabel(es eventsources.EventSource) string {
+	return fmt.Sprintf("%T", es)
 }
 
-// secretKey inspects the request for a contexted define key
-// and then falls back to a webhook instance defined key.
-func (wh Webhook) secretKey(r *http.Request) []byte {
-	sk := wh.SecretKey
-	k := r.Context().Value(CtxSecretTokenKey)
-	v, ok := k.([]byte)
-	if ok && v != nil {
-		sk = v
+// secretKeys resolves every secret currently valid for r, via
+// wh.Secrets, with a contexted override (CtxSecretTokenKey) taking
+// priority when present.
+func (wh Webhook) secretKeys(r *http.Request) ([][]byte, error) {
+	if k := r.Context().Value(CtxSecretTokenKey); k != nil {
+		if v, ok := k.([]byte); ok && v != nil {
+			return [][]byte{v}, nil
+		}
 	}
-	return sk
+
+	return wh.Secrets.Resolve(r.Context(), r, wh.EventSource)
 }
 
-func (wh *Webhook) Handler(w http.ResponseWriter, r *http.Request) {
-	var payload []byte
-	var err error
-	var e eventsources.Event
+// validate resolves candidates and validates r's payload against them.
+// If wh.Secrets caches its underlying lookups (see secrets.Cached) and
+// nothing matches, it invalidates that cache entry and retries once -
+// a rotation may have landed after the cache's last fetch but inside
+// its TTL, and this is the one signal available at this layer that
+// the cached value might be stale.
+//
+// r.Body is read into memory once up front, since
+// EventSource.ValidatePayload reads it to get the bytes it signs (see
+// cloudevents.EventSource.ValidatePayload) and http.Request.Body is a
+// one-shot stream: without re-seeding it before each call, the retry
+// below would validate against an already-drained body and fail
+// regardless of whether the freshly-resolved secret is correct.
+func (wh *Webhook) validate(r *http.Request) ([]byte, []byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading request body: %w", err)
+	}
 
-	secretKey := wh.secretKey(r)
+	candidates, err := wh.secretKeys(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving secret(s): %w", err)
+	}
 
-	if payload, err = wh.EventSource.ValidatePayload(r, secretKey); err != nil {
-		log.WithFields(log.Fields{
-			"error":   err.Error(),
-			"payload": payload,
-		}).Errorf("unable to validate request")
-		http.Error(w, "error", http.StatusBadRequest)
-		return
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	payload, matched, err := wh.EventSource.ValidatePayload(r, candidates)
+	if err == nil {
+		return payload, matched, nil
 	}
 
-	defer r.Body.Close()
+	inv, ok := wh.Secrets.(secrets.Invalidator)
+	if !ok {
+		return payload, matched, err
+	}
 
-	if e, err = wh.EventSource.Event(r, payload); err != nil {
-		log.WithFields(log.Fields{
-			"error":   err.Error(),
-			"payload": payload,
-		}).Errorf("unable to convert payload to event")
-		http.Error(w, "error", http.StatusBadRequest)
-		return
+	if iErr := inv.Invalidate(r.Context(), r, wh.EventSource); iErr != nil {
+		log.WithFields(log.Fields{"error": iErr.Error()}).Errorf("error invalidating cached secret(s)")
+		return payload, matched, err
+	}
+
+	candidates, cErr := wh.secretKeys(r)
+	if cErr != nil {
+		return nil, nil, err
 	}
 
-	tracer, closer, err := wh.Tracers.RequestScoped(r, wh.EventSource)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return wh.EventSource.ValidatePayload(r, candidates)
+}
+
+// Handler is the receiver stage of the webhook pipeline: it only
+// validates the delivery and durably queues it, so a source never sees
+// a failed delivery because the SpanStore happened to be unavailable.
+// The actual span-store mutation happens later, out of the request
+// path, in a WorkerPool.
+func (wh *Webhook) Handler(w http.ResponseWriter, r *http.Request) {
+	payload, matched, err := wh.validate(r)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error":   err.Error(),
 			"payload": payload,
-		}).Errorf("error getting tracer from request")
+		}).Errorf("unable to validate request")
 		http.Error(w, "error", http.StatusBadRequest)
 		return
 	}
-	defer closer.Close()
+	defer r.Body.Close()
+
+	// matched identifies which of the candidate secrets accepted this
+	// delivery, for audit logging during/after a rotation.
+	log.WithFields(log.Fields{
+		"secret": auditHash(matched),
+	}).Debugf("validated webhook delivery")
+
+	re := queue.RawEvent{
+		Source:     sourceLabel(wh.EventSource),
+		Header:     r.Header,
+		Payload:    payload,
+		EnqueuedAt: time.Now(),
+	}
 
-	if err := wh.handleEvent(r.Context(), tracer, e); err != nil {
+	if err := wh.Queue.Enqueue(r.Context(), re); err != nil {
 		log.WithFields(log.Fields{
 			"error":   err.Error(),
 			"payload": payload,
-			"event":   e,
-		}).Errorf("error processinng event")
-		http.Error(w, "error", http.StatusBadRequest)
+		}).Errorf("unable to queue event")
+		http.Error(w, "error", http.StatusServiceUnavailable)
 		return
 	}
 
-	w.Write([]byte("success"))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("accepted"))
 }
 
-func (wh *Webhook) handleStartEvent(ctx context.Context, tracer opentracing.Tracer, e eventsources.Event) error {
-	// check to see if this event has a parent span
+func (wh *Webhook) handleStartEvent(ctx context.Context, tracer opentracing.Tracer, r *http.Request, e eventsources.Event) error {
+	opts := make([]opentracing.StartSpanOption, 0)
+	var parentSpanCtx opentracing.SpanContext
+
+	// Our own in-process SpanStore takes priority: it's derived from
+	// the signed event payload, whereas B3/W3C trace headers are
+	// client-controlled and unsigned, so anyone who can reach this
+	// endpoint (not just a genuinely instrumented upstream caller)
+	// could otherwise dictate parentage by setting them. Extraction is
+	// only a fallback for when the local store has no match - e.g. the
+	// first event from a CI system or another instrumented service
+	// that Valuestream hasn't seen a parent span from yet.
 	parentID, err := e.ParentSpanID()
 	if err != nil {
 		return err
 	}
 
-	opts := make([]opentracing.StartSpanOption, 0)
-
-	// if it does than make sure to establish the ChildOf relationship
 	if parentID != nil {
 		parentSpan, err := wh.Traces.Get(ctx, tracer, *parentID)
 		if err != nil {
@@ -124,7 +216,29 @@ func (wh *Webhook) handleStartEvent(ctx context.Context, tracer opentracing.Trac
 		}
 
 		if parentSpan != nil {
-			opts = append(opts, opentracing.ChildOf(parentSpan.Context()))
+			parentSpanCtx = parentSpan.Context()
+			opts = append(opts, opentracing.ChildOf(parentSpanCtx))
+		}
+	}
+
+	if parentSpanCtx == nil {
+		if extracted, err := ExtractSpanContext(tracer, r); err == nil && extracted != nil {
+			parentSpanCtx = extracted
+			opts = append(opts, opentracing.ChildOf(extracted))
+		}
+	}
+
+	if wh.Sampling != nil {
+		decision, err := wh.Sampling.Sample(ctx, sourceLabel(wh.EventSource), sampling.ParentSampled(parentSpanCtx), e)
+		if err != nil {
+			return err
+		}
+
+		if !decision.Sample {
+			log.WithFields(log.Fields{
+				"reason": decision.Reason,
+			}).Debugf("dropping span")
+			return wh.markSampledOut(ctx, tracer, e)
 		}
 	}
 
@@ -164,6 +278,52 @@ func (wh *Webhook) handleStartEvent(ctx context.Context, tracer opentracing.Trac
 		return err
 	}
 
+	if wh.Emitter != nil {
+		if err := wh.Emitter.EmitStart(ctx, span, e); err != nil {
+			log.WithFields(log.Fields{"error": err.Error()}).Errorf("error emitting span start")
+		}
+	}
+
+	return nil
+}
+
+// markSampledOut records a marker span for an event the SamplingPolicy
+// dropped, in the same SpanStore slots a real span would occupy.
+// Without it, the matching end event would find nothing under
+// e.SpanID() and fail with traces.SpanMissingError, even though the
+// drop was intentional.
+//
+// The marker is started from the request's real tracer, not a
+// NoopTracer, since a SpanStore backed by something that round-trips
+// through the configured tracer (e.g. Redis) wouldn't be able to read
+// a NoopTracer span back. It's tagged as not sampled (via
+// ext.SamplingPriority) so handleEndEvent can recognise and skip it,
+// the same way it recognises an upstream "not sampled" verdict via
+// sampling.ParentSampled - otherwise the matching end event would
+// treat it like any other span and re-emit its lifecycle, defeating
+// the point of dropping it.
+func (wh *Webhook) markSampledOut(ctx context.Context, tracer opentracing.Tracer, e eventsources.Event) error {
+	marker := tracer.StartSpan(e.OperationName())
+	ext.SamplingPriority.Set(marker, 0)
+
+	spanID, err := e.SpanID()
+	if err != nil {
+		return err
+	}
+
+	if err := wh.Spans.Set(ctx, spanID, marker); err != nil {
+		return err
+	}
+
+	traceID, err := e.TraceID()
+	if err != nil {
+		return err
+	}
+
+	if traceID != nil {
+		wh.Traces.Set(ctx, *traceID, marker)
+	}
+
 	return nil
 }
 
@@ -184,15 +344,30 @@ func (wh *Webhook) handleEndEvent(ctx context.Context, tracer opentracing.Tracer
 		}
 	}
 
-	// TODO add tags on end event
-	isE, err := e.IsError()
-	if err != nil {
-		return err
+	// A marker left by markSampledOut carries an explicit "not sampled"
+	// verdict in its own SpanContext; honor it by finishing/cleaning up
+	// without tagging or telling Emitter, so a span sampling dropped
+	// never gets a span.end lifecycle event of its own.
+	sampledOut := sampling.ParentSampled(span.Context())
+	dropped := sampledOut != nil && !*sampledOut
+
+	if !dropped {
+		// TODO add tags on end event
+		isE, err := e.IsError()
+		if err != nil {
+			return err
+		}
+		span.SetTag("error", isE)
 	}
 
-	span.SetTag("error", isE)
 	span.Finish()
 
+	if !dropped && wh.Emitter != nil {
+		if err := wh.Emitter.EmitEnd(ctx, span, e); err != nil {
+			log.WithFields(log.Fields{"error": err.Error()}).Errorf("error emitting span end")
+		}
+	}
+
 	if err := wh.Spans.Delete(ctx, spanID); err != nil {
 		return err
 	}
@@ -212,7 +387,9 @@ func (wh *Webhook) handleEndEvent(ctx context.Context, tracer opentracing.Tracer
 	return nil
 }
 
-func (wh *Webhook) handleEvent(ctx context.Context, tracer opentracing.Tracer, e eventsources.Event) error {
+func (wh *Webhook) handleEvent(r *http.Request, tracer opentracing.Tracer, e eventsources.Event) error {
+	ctx := r.Context()
+
 	state, err := e.State()
 
 	if err != nil {
@@ -221,7 +398,7 @@ func (wh *Webhook) handleEvent(ctx context.Context, tracer opentracing.Tracer, e
 
 	switch state {
 	case eventsources.StartState:
-		return wh.handleStartEvent(ctx, tracer, e)
+		return wh.handleStartEvent(ctx, tracer, r, e)
 	case eventsources.EndState:
 		return wh.handleEndEvent(ctx, tracer, e)
 	}
@@ -0,0 +1,248 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+	"github.com/ImpactInsights/valuestream/sampling"
+	"github.com/ImpactInsights/valuestream/traces"
+	"github.com/opentracing/opentracing-go"
+)
+
+// bodyMatchEventSource stands in for a real HMAC-checking EventSource:
+// it reads r.Body (exactly once, the same way a signature check reads
+// the bytes it hashes) and "validates" by requiring the body equal one
+// of candidates, rather than an actual signature.
+type bodyMatchEventSource struct{}
+
+func (bodyMatchEventSource) ValidatePayload(r *http.Request, candidates [][]byte) ([]byte, []byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range candidates {
+		if bytes.Equal(body, c) {
+			return body, c, nil
+		}
+	}
+
+	return body, nil, errSignatureMismatch
+}
+
+func (bodyMatchEventSource) Event(r *http.Request, payload []byte) (eventsources.Event, error) {
+	return nil, nil
+}
+
+var errSignatureMismatch = &signatureMismatchError{}
+
+type signatureMismatchError struct{}
+
+func (*signatureMismatchError) Error() string { return "signature mismatch" }
+
+// staleThenFreshResolver simulates a secrets.Cached whose cached value
+// went stale mid-TTL: it resolves to a wrong secret until Invalidate is
+// called, after which it resolves to the right one.
+type staleThenFreshResolver struct {
+	invalidated bool
+}
+
+func (r *staleThenFreshResolver) Resolve(ctx context.Context, req *http.Request, es eventsources.EventSource) ([][]byte, error) {
+	if r.invalidated {
+		return [][]byte{[]byte("fresh-secret")}, nil
+	}
+	return [][]byte{[]byte("stale-secret")}, nil
+}
+
+func (r *staleThenFreshResolver) Invalidate(ctx context.Context, req *http.Request, es eventsources.EventSource) error {
+	r.invalidated = true
+	return nil
+}
+
+// TestValidateRetriesWithRereadableBody pins the cache-invalidate-and-
+// retry path in Webhook.validate: the request body must still be
+// readable on the retry's ValidatePayload call, not left drained by
+// the first one, or a rotation landing mid-TTL would never actually
+// validate even once the correct secret is resolved.
+func TestValidateRetriesWithRereadableBody(t *testing.T) {
+	wh := &Webhook{
+		EventSource: bodyMatchEventSource{},
+		Secrets:     &staleThenFreshResolver{},
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("fresh-secret")))
+
+	payload, matched, err := wh.validate(r)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if string(payload) != "fresh-secret" {
+		t.Fatalf("payload = %q, want %q", payload, "fresh-secret")
+	}
+	if string(matched) != "fresh-secret" {
+		t.Fatalf("matched = %q, want %q", matched, "fresh-secret")
+	}
+}
+
+// memorySpanStore is a minimal traces.SpanStore that actually keeps
+// the spans it's given, unlike worker_test.go's fakeSpanStore (which
+// exists purely to make every lookup miss).
+type memorySpanStore struct {
+	mu    sync.Mutex
+	spans map[string]opentracing.Span
+}
+
+func newMemorySpanStore() *memorySpanStore {
+	return &memorySpanStore{spans: make(map[string]opentracing.Span)}
+}
+
+func (s *memorySpanStore) Get(ctx context.Context, tracer opentracing.Tracer, id string) (opentracing.Span, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spans[id], nil
+}
+
+func (s *memorySpanStore) Set(ctx context.Context, id string, span opentracing.Span) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spans[id] = span
+	return nil
+}
+
+func (s *memorySpanStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.spans, id)
+	return nil
+}
+
+// denySampling drops every event, the same way a real SamplingPolicy
+// would for a high-volume source configured below its sample rate.
+type denySampling struct{}
+
+func (denySampling) Sample(ctx context.Context, source string, parentSampled *bool, e eventsources.Event) (sampling.Decision, error) {
+	return sampling.Decision{Sample: false, Reason: "test deny"}, nil
+}
+
+// samplingAwareSpanContext lets a test stand in for the part of a real
+// tracer's SpanContext (e.g. jaeger's) that markSampledOut/
+// handleEndEvent actually depend on: reporting back the sampling
+// decision a prior ext.SamplingPriority.Set call recorded. NoopTracer's
+// own SpanContext has no such notion, so it can't exercise this path.
+type samplingAwareSpanContext struct {
+	sampled bool
+}
+
+func (*samplingAwareSpanContext) ForeachBaggageItem(handler func(k, v string) bool) {}
+func (c *samplingAwareSpanContext) IsSampled() bool                                 { return c.sampled }
+
+// samplingAwareSpan wraps a real Span so Finish/SetOperationName/... all
+// behave exactly as the underlying tracer's own span would, overriding
+// only Context/SetTag to keep samplingAwareSpanContext.sampled in sync
+// with the "sampling.priority" tag, the way a real tracer wires its
+// SpanContext to ext.SamplingPriority.
+type samplingAwareSpan struct {
+	opentracing.Span
+	ctx *samplingAwareSpanContext
+}
+
+func (s *samplingAwareSpan) Context() opentracing.SpanContext { return s.ctx }
+
+func (s *samplingAwareSpan) SetTag(key string, value interface{}) opentracing.Span {
+	if key == "sampling.priority" {
+		switch p := value.(type) {
+		case uint16:
+			s.ctx.sampled = p > 0
+		case int:
+			s.ctx.sampled = p > 0
+		}
+	}
+	s.Span.SetTag(key, value)
+	return s
+}
+
+// samplingAwareTracer wraps a real Tracer, only so every span it starts
+// comes back as a samplingAwareSpan.
+type samplingAwareTracer struct {
+	opentracing.Tracer
+}
+
+func (t samplingAwareTracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	span := t.Tracer.StartSpan(operationName, opts...)
+	return &samplingAwareSpan{Span: span, ctx: &samplingAwareSpanContext{sampled: true}}
+}
+
+// fakeSampledEvent is a single logical operation's start or end event,
+// sharing one SpanID so handleEndEvent looks up the same marker
+// handleStartEvent (via markSampledOut) left behind.
+type fakeSampledEvent struct {
+	spanID string
+	state  eventsources.EventState
+}
+
+func (e fakeSampledEvent) OperationName() string                   { return "test.op" }
+func (e fakeSampledEvent) State() (eventsources.EventState, error) { return e.state, nil }
+func (e fakeSampledEvent) Tags() (map[string]string, error)        { return nil, nil }
+func (e fakeSampledEvent) SpanID() (string, error)                 { return e.spanID, nil }
+func (e fakeSampledEvent) ParentSpanID() (*string, error)          { return nil, nil }
+func (e fakeSampledEvent) TraceID() (*string, error)               { return nil, nil }
+func (e fakeSampledEvent) IsError() (bool, error)                  { return false, nil }
+
+// recordingEmitter records whether EmitEnd was ever called, so the
+// test can assert a sampled-out span never gets a span.end lifecycle
+// event republished for it.
+type recordingEmitter struct {
+	endCalled bool
+}
+
+func (e *recordingEmitter) EmitStart(ctx context.Context, span opentracing.Span, ev eventsources.Event) error {
+	return nil
+}
+
+func (e *recordingEmitter) EmitEnd(ctx context.Context, span opentracing.Span, ev eventsources.Event) error {
+	e.endCalled = true
+	return nil
+}
+
+// TestSampledOutRoundTripSkipsEmitEndAndSpanMissingError guards against
+// regressing the bug fixed by markSampledOut/handleEndEvent's dropped
+// check: a start event the SamplingPolicy drops must still let its
+// matching end event complete cleanly, with no traces.SpanMissingError
+// (the marker satisfies the SpanStore lookup) and no Emitter.EmitEnd
+// call (the drop must not resurface as a republished lifecycle event).
+func TestSampledOutRoundTripSkipsEmitEndAndSpanMissingError(t *testing.T) {
+	store := newMemorySpanStore()
+	emitter := &recordingEmitter{}
+	wh := &Webhook{
+		Traces:   store,
+		Spans:    store,
+		Sampling: denySampling{},
+		Emitter:  emitter,
+	}
+
+	tracer := samplingAwareTracer{Tracer: opentracing.NoopTracer{}}
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	start := fakeSampledEvent{spanID: "span-1", state: eventsources.StartState}
+	if err := wh.handleEvent(r, tracer, start); err != nil {
+		t.Fatalf("handleEvent(start): %v", err)
+	}
+
+	end := fakeSampledEvent{spanID: "span-1", state: eventsources.EndState}
+	if err := wh.handleEvent(r, tracer, end); err != nil {
+		if _, ok := err.(traces.SpanMissingError); ok {
+			t.Fatalf("handleEvent(end) = %v, want no SpanMissingError for a sampled-out span's marker", err)
+		}
+		t.Fatalf("handleEvent(end): %v", err)
+	}
+
+	if emitter.endCalled {
+		t.Fatalf("EmitEnd was called for a sampled-out span, want it skipped")
+	}
+}
@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// ExtractSpanContext attempts to recover an upstream opentracing.SpanContext
+// from an inbound webhook request. It first tries the tracer's own
+// registered HTTPHeaders propagator (e.g. jaegercfg.Propagators for B3/Jaeger
+// headers, or the zipkin-go-opentracing B3 propagator, if the Tracers
+// implementation registered one on tracer construction), and falls back to
+// parsing B3 (single- or multi-header) or W3C traceparent/tracestate headers
+// itself via propagators below, for callers whose headers the configured
+// Tracer doesn't otherwise recognise.
+//
+// It returns a nil SpanContext, nil error when the request simply carries
+// no trace headers at all, which is the common case for sources that have
+// never heard of distributed tracing.
+func ExtractSpanContext(tracer opentracing.Tracer, r *http.Request) (opentracing.SpanContext, error) {
+	sc, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+	if err == nil {
+		return sc, nil
+	}
+	if err != opentracing.ErrSpanContextNotFound {
+		return nil, err
+	}
+
+	for _, p := range propagators {
+		sc, ok, err := p(r)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return sc, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// InjectSpanContext writes span's context onto an outbound request using
+// the tracer's registered HTTPHeaders propagator, so that callbacks
+// Valuestream makes to the originating event source (the GitHub status
+// API, a Jira comment, ...) carry the current trace and let the upstream
+// tool stitch its own spans onto ours. cloudevents.Emitter is the one
+// caller today, copying whatever headers this produces onto an outbound
+// CloudEvent's extensions (see cloudevents.injectTraceContext) so the
+// trace survives a hop through a Sink that isn't itself plain HTTP.
+func InjectSpanContext(tracer opentracing.Tracer, span opentracing.Span, req *http.Request) error {
+	return tracer.Inject(
+		span.Context(),
+		opentracing.HTTPHeaders,
+		opentracing.HTTPHeadersCarrier(req.Header),
+	)
+}
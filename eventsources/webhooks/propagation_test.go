@@ -0,0 +1,156 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// noMatchTracer stands in for a real Tracer (Jaeger, Zipkin, ...) whose own
+// registered propagator doesn't recognise a given request's headers -
+// ExtractSpanContext is documented to fall back to its own B3/W3C
+// propagators in exactly this case.
+type noMatchTracer struct {
+	opentracing.NoopTracer
+}
+
+func (noMatchTracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	return nil, opentracing.ErrSpanContextNotFound
+}
+
+func TestExtractB3Single(t *testing.T) {
+	cases := []struct {
+		name        string
+		header      string
+		wantOK      bool
+		wantErr     bool
+		wantSampled *bool
+	}{
+		{name: "absent", header: "", wantOK: false},
+		{name: "explicit deny", header: "0", wantOK: false},
+		{name: "trace and span only", header: "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", wantOK: true},
+		{name: "sampled", header: "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1", wantOK: true, wantSampled: boolPtr(true)},
+		{name: "not sampled", header: "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0", wantOK: true, wantSampled: boolPtr(false)},
+		{name: "malformed", header: "not-hex", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if c.header != "" {
+				r.Header.Set("b3", c.header)
+			}
+
+			sc, ok, err := extractB3Single(r)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, c.wantErr)
+			}
+			if ok != c.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, c.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			if c.wantSampled != nil {
+				if got := sc.(wireSpanContext).IsSampled(); got != *c.wantSampled {
+					t.Fatalf("IsSampled() = %v, want %v", got, *c.wantSampled)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractB3Multi(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-B3-TraceId", "4bf92f3577b34da6a3ce929d0e0e4736")
+	r.Header.Set("X-B3-SpanId", "00f067aa0ba902b7")
+	r.Header.Set("X-B3-ParentSpanId", "05e3ac9a4f6e3b90")
+	r.Header.Set("X-B3-Sampled", "1")
+
+	sc, ok, err := extractB3Multi(r)
+	if err != nil {
+		t.Fatalf("extractB3Multi: %v", err)
+	}
+	if !ok {
+		t.Fatalf("ok = false, want true")
+	}
+	if !sc.(wireSpanContext).IsSampled() {
+		t.Fatalf("IsSampled() = false, want true")
+	}
+
+	if _, ok, _ := extractB3Multi(httptest.NewRequest(http.MethodPost, "/", nil)); ok {
+		t.Fatalf("ok = true for a request with no X-B3-* headers")
+	}
+}
+
+func TestExtractTraceContext(t *testing.T) {
+	cases := []struct {
+		name        string
+		header      string
+		wantOK      bool
+		wantErr     bool
+		wantSampled bool
+	}{
+		{name: "absent", header: "", wantOK: false},
+		{name: "sampled", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantOK: true, wantSampled: true},
+		{name: "not sampled", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", wantOK: true, wantSampled: false},
+		{name: "wrong version", header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "malformed", header: "00-bad-00f067aa0ba902b7-01", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if c.header != "" {
+				r.Header.Set("traceparent", c.header)
+			}
+
+			sc, ok, err := extractTraceContext(r)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, c.wantErr)
+			}
+			if ok != c.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, c.wantOK)
+			}
+			if ok && sc.(wireSpanContext).IsSampled() != c.wantSampled {
+				t.Fatalf("IsSampled() = %v, want %v", sc.(wireSpanContext).IsSampled(), c.wantSampled)
+			}
+		})
+	}
+}
+
+// TestExtractSpanContextFallsBackToPropagators pins the precedence
+// ExtractSpanContext documents: it only tries its own B3/W3C propagators
+// once the Tracer's own registered propagator reports it found nothing.
+func TestExtractSpanContextFallsBackToPropagators(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	sc, err := ExtractSpanContext(noMatchTracer{}, r)
+	if err != nil {
+		t.Fatalf("ExtractSpanContext: %v", err)
+	}
+	if sc == nil {
+		t.Fatalf("sc = nil, want a SpanContext recovered from the traceparent header")
+	}
+	if !sc.(wireSpanContext).IsSampled() {
+		t.Fatalf("IsSampled() = false, want true")
+	}
+}
+
+func TestExtractSpanContextNoHeaders(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	sc, err := ExtractSpanContext(noMatchTracer{}, r)
+	if err != nil {
+		t.Fatalf("ExtractSpanContext: %v", err)
+	}
+	if sc != nil {
+		t.Fatalf("sc = %v, want nil for a request with no trace headers at all", sc)
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,159 @@
+package webhooks
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// headerPropagator recovers a SpanContext from one specific wire format. It
+// reports ok=false, nil error when r simply doesn't carry that format's
+// headers, and a non-nil error when it does but they're malformed.
+type headerPropagator func(r *http.Request) (sc opentracing.SpanContext, ok bool, err error)
+
+// propagators is the pluggable registry ExtractSpanContext falls back to
+// once the configured Tracer's own registered propagator comes up empty -
+// e.g. a Jaeger-backed Tracers that only registered its native header
+// format but the caller sent B3 or W3C traceparent headers instead. Tried
+// in this order; the first match wins.
+var propagators = []headerPropagator{
+	extractB3Single,
+	extractB3Multi,
+	extractTraceContext,
+}
+
+// wireSpanContext is a minimal, Tracer-agnostic opentracing.SpanContext for
+// a SpanContext recovered by one of the propagators above. It only needs to
+// satisfy opentracing.SpanContext (for opentracing.ChildOf) and, where the
+// wire format carries one, sampling.sampledSpanContext (so
+// sampling.ParentSampled can honour the upstream sampling decision) - it
+// never needs to be understood by the configured Tracer's own concrete
+// SpanContext type, since Valuestream's own span linkage (traces.SpanStore)
+// keys spans by application-level IDs, not by trace/span ID.
+type wireSpanContext struct {
+	traceID    string
+	spanID     string
+	sampled    bool
+	hasSampled bool
+}
+
+func (wireSpanContext) ForeachBaggageItem(handler func(k, v string) bool) {}
+
+func (c wireSpanContext) IsSampled() bool { return c.hasSampled && c.sampled }
+
+// extractB3Single parses the single-header B3 format:
+// "b3: {traceid}-{spanid}-{sampled}-{parentspanid}", per
+// https://github.com/openzipkin/b3-propagation#single-header. Only
+// traceid-spanid is mandatory; sampled and parentspanid may be omitted.
+func extractB3Single(r *http.Request) (opentracing.SpanContext, bool, error) {
+	v := r.Header.Get("b3")
+	if v == "" {
+		return nil, false, nil
+	}
+	if v == "0" {
+		// An unadorned "0" means "do not sample", with no IDs to extract.
+		return nil, false, nil
+	}
+
+	parts := strings.Split(v, "-")
+	if len(parts) < 2 || !validHexID(parts[0]) || !validHexID(parts[1]) {
+		return nil, false, fmt.Errorf("malformed b3 header %q", v)
+	}
+
+	sc := wireSpanContext{traceID: parts[0], spanID: parts[1]}
+	if len(parts) >= 3 {
+		if sampled, ok := parseB3Sampled(parts[2]); ok {
+			sc.hasSampled, sc.sampled = true, sampled
+		}
+	}
+
+	return sc, true, nil
+}
+
+// extractB3Multi parses the four X-B3-* headers (X-B3-TraceId, X-B3-SpanId,
+// X-B3-ParentSpanId, X-B3-Sampled). X-B3-ParentSpanId isn't read here:
+// Valuestream establishes its own parent/child relationship from
+// e.ParentSpanID() via traces.SpanStore (see handleStartEvent), and only
+// needs the upstream trace/span ID and sampling decision from this header
+// set.
+func extractB3Multi(r *http.Request) (opentracing.SpanContext, bool, error) {
+	traceID := r.Header.Get("X-B3-TraceId")
+	spanID := r.Header.Get("X-B3-SpanId")
+	if traceID == "" && spanID == "" {
+		return nil, false, nil
+	}
+	if !validHexID(traceID) || !validHexID(spanID) {
+		return nil, false, fmt.Errorf("malformed X-B3-TraceId/X-B3-SpanId headers")
+	}
+
+	sc := wireSpanContext{traceID: traceID, spanID: spanID}
+	if sampled, ok := parseB3Sampled(r.Header.Get("X-B3-Sampled")); ok {
+		sc.hasSampled, sc.sampled = true, sampled
+	}
+
+	return sc, true, nil
+}
+
+func parseB3Sampled(v string) (sampled bool, ok bool) {
+	switch v {
+	case "1", "true", "d":
+		return true, true
+	case "0", "false":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// extractTraceContext parses the W3C traceparent header:
+// "00-<32hex traceid>-<16hex spanid>-<2hex flags>", per
+// https://www.w3.org/TR/trace-context/#traceparent-header. tracestate is
+// deliberately not parsed - it's opaque to everyone but the vendor that
+// wrote it - and isn't needed for anything Valuestream itself reads off a
+// parent SpanContext.
+func extractTraceContext(r *http.Request) (opentracing.SpanContext, bool, error) {
+	v := r.Header.Get("traceparent")
+	if v == "" {
+		return nil, false, nil
+	}
+
+	parts := strings.Split(v, "-")
+	if len(parts) != 4 || parts[0] != "00" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 ||
+		!validHexID(parts[1]) || !validHexID(parts[2]) {
+		return nil, false, fmt.Errorf("malformed traceparent header %q", v)
+	}
+
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return nil, false, fmt.Errorf("malformed traceparent flags %q: %w", parts[3], err)
+	}
+
+	return wireSpanContext{
+		traceID:    parts[1],
+		spanID:     parts[2],
+		hasSampled: true,
+		sampled:    flags&0x1 == 1,
+	}, true, nil
+}
+
+// validHexID reports whether s is a non-empty, all-zero-excluded hex string,
+// as B3/W3C trace and span IDs are required to be.
+func validHexID(s string) bool {
+	if s == "" {
+		return false
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return false
+	}
+	for _, r := range s {
+		if r != '0' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ImpactInsights/valuestream/queue"
+	"github.com/ImpactInsights/valuestream/traces"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxAttempts bounds how many times a WorkerPool retries an
+	// event before handing it to the DeadLetterSink. Retries after a
+	// traces.SpanMissingError are not counted against it; only
+	// DefaultParentTTL bounds those.
+	DefaultMaxAttempts = 10
+
+	// DefaultParentTTL is how long a WorkerPool keeps retrying an
+	// event whose parent span hasn't shown up yet (see
+	// traces.SpanMissingError) before giving up on it.
+	DefaultParentTTL = 15 * time.Minute
+
+	// maxBackoff caps the exponential delay applied between retries.
+	maxBackoff = 5 * time.Minute
+)
+
+// WorkerPool pulls queued RawEvents off a Webhook's EventQueue and runs
+// the same handleEvent pipeline the old inline Handler used to run
+// synchronously, but off the originating request's lifetime. This is
+// what lets Handler 202 a delivery immediately: processing, and its
+// retries, happen here.
+type WorkerPool struct {
+	wh *Webhook
+
+	MaxAttempts int
+	ParentTTL   time.Duration
+	DeadLetter  queue.DeadLetterSink
+}
+
+// NewWorkerPool returns a WorkerPool draining wh.Queue, sending events
+// it gives up on to dl (which may be nil to discard them).
+func NewWorkerPool(wh *Webhook, dl queue.DeadLetterSink) *WorkerPool {
+	return &WorkerPool{
+		wh:          wh,
+		MaxAttempts: DefaultMaxAttempts,
+		ParentTTL:   DefaultParentTTL,
+		DeadLetter:  dl,
+	}
+}
+
+// Run starts n concurrent workers pulling from wh.Queue. It returns
+// once all workers have stopped, which happens when ctx is cancelled.
+func (p *WorkerPool) Run(ctx context.Context, n int) {
+	done := make(chan struct{}, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			p.loop(ctx)
+			done <- struct{}{}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		<-done
+	}
+}
+
+func (p *WorkerPool) loop(ctx context.Context) {
+	for {
+		e, ack, err := p.wh.Queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.WithFields(log.Fields{"error": err.Error()}).Errorf("error dequeuing event")
+			continue
+		}
+
+		p.process(ctx, e, ack)
+	}
+}
+
+// process replays a RawEvent through the same conversion, tracer
+// lookup, and handleEvent logic the receiver used to run inline.
+func (p *WorkerPool) process(ctx context.Context, e queue.RawEvent, ack queue.Ack) {
+	r := (&http.Request{Header: http.Header(e.Header)}).WithContext(ctx)
+
+	ev, err := p.wh.EventSource.Event(r, e.Payload)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err.Error()}).Errorf("unable to convert payload to event")
+		p.giveUp(ctx, e, ack, err)
+		return
+	}
+
+	tracer, closer, err := p.wh.Tracers.RequestScoped(r, p.wh.EventSource)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err.Error()}).Errorf("error getting tracer from request")
+		p.retry(ctx, e, ack, err)
+		return
+	}
+	defer closer.Close()
+
+	if err := p.wh.handleEvent(r, tracer, ev); err != nil {
+		if _, ok := err.(traces.SpanMissingError); ok {
+			// Parent/child webhooks often arrive out of order; keep
+			// retrying until ParentTTL elapses instead of dead-lettering
+			// immediately. This is gated solely on ParentTTL, not
+			// MaxAttempts: at the default 5-minute backoff cap,
+			// MaxAttempts' 10-attempt budget is exhausted in under nine
+			// minutes, well inside the 15-minute grace window a merely
+			// late (not missing) parent is supposed to get.
+			if time.Since(e.EnqueuedAt) > p.ParentTTL {
+				p.giveUp(ctx, e, ack, err)
+				return
+			}
+			if err := ack.Nack(backoff(e.Attempts)); err != nil {
+				log.WithFields(log.Fields{"error": err.Error()}).Errorf("error requeuing event")
+			}
+			return
+		}
+
+		log.WithFields(log.Fields{"error": err.Error(), "event": ev}).Errorf("error processing event")
+		p.retry(ctx, e, ack, err)
+		return
+	}
+
+	ack.Ack()
+}
+
+func (p *WorkerPool) retry(ctx context.Context, e queue.RawEvent, ack queue.Ack, cause error) {
+	if e.Attempts+1 >= p.MaxAttempts {
+		p.giveUp(ctx, e, ack, cause)
+		return
+	}
+
+	if err := ack.Nack(backoff(e.Attempts)); err != nil {
+		log.WithFields(log.Fields{"error": err.Error()}).Errorf("error requeuing event")
+	}
+}
+
+func (p *WorkerPool) giveUp(ctx context.Context, e queue.RawEvent, ack queue.Ack, cause error) {
+	if p.DeadLetter != nil {
+		if err := p.DeadLetter.Put(ctx, e, cause); err != nil {
+			log.WithFields(log.Fields{"error": err.Error()}).Errorf("error writing to dead-letter sink")
+		}
+	}
+	ack.Ack()
+}
+
+// backoff returns the exponential delay to apply before redelivering
+// an event after its attempt'th failure, capped at maxBackoff.
+func backoff(attempt int) time.Duration {
+	d := time.Second * time.Duration(uint(1)<<uint(attempt))
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,167 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+	"github.com/ImpactInsights/valuestream/queue"
+	"github.com/opentracing/opentracing-go"
+)
+
+// TestBackoff pins the exponential-delay math the rest of this file's
+// tests (and the review that prompted them) depend on.
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 1 * time.Second},
+		{1, 2 * time.Second},
+		{4, 16 * time.Second},
+		{8, 256 * time.Second},
+		{9, maxBackoff}, // 512s would exceed the 5-minute cap
+		{30, maxBackoff},
+	}
+
+	for _, c := range cases {
+		if got := backoff(c.attempt); got != c.want {
+			t.Errorf("backoff(%d) = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+// TestMaxAttemptsBackoffBudget pins the exact math DefaultMaxAttempts
+// and maxBackoff produce together: the sum of backoff(0)..backoff(8)
+// (the 9 retries a transient failure gets before the 10th attempt
+// hits DefaultMaxAttempts) comes to well under DefaultParentTTL. A
+// SpanMissingError retry that was gated on both, instead of solely on
+// ParentTTL as documented, would dead-letter a merely-late parent
+// before its 15-minute grace window was up.
+func TestMaxAttemptsBackoffBudget(t *testing.T) {
+	var total time.Duration
+	for a := 0; a < DefaultMaxAttempts-1; a++ {
+		total += backoff(a)
+	}
+
+	if want := 511 * time.Second; total != want {
+		t.Fatalf("sum of backoffs before MaxAttempts = %v, want %v", total, want)
+	}
+	if total >= DefaultParentTTL {
+		t.Fatalf("backoff budget %v already exceeds DefaultParentTTL %v", total, DefaultParentTTL)
+	}
+}
+
+// fakeEvent always reports an EndState with no span ever found for it,
+// which is what drives handleEndEvent to traces.SpanMissingError.
+type fakeEvent struct{}
+
+func (fakeEvent) OperationName() string                  { return "test.op" }
+func (fakeEvent) State() (eventsources.EventState, error) { return eventsources.EndState, nil }
+func (fakeEvent) Tags() (map[string]string, error)        { return nil, nil }
+func (fakeEvent) SpanID() (string, error)                 { return "span-1", nil }
+func (fakeEvent) ParentSpanID() (*string, error)          { return nil, nil }
+func (fakeEvent) TraceID() (*string, error)               { return nil, nil }
+func (fakeEvent) IsError() (bool, error)                  { return false, nil }
+
+type fakeEventSource struct{}
+
+func (fakeEventSource) ValidatePayload(r *http.Request, candidates [][]byte) ([]byte, []byte, error) {
+	return nil, nil, nil
+}
+
+func (fakeEventSource) Event(r *http.Request, payload []byte) (eventsources.Event, error) {
+	return fakeEvent{}, nil
+}
+
+type fakeTracers struct{}
+
+func (fakeTracers) RequestScoped(r *http.Request, es eventsources.EventSource) (opentracing.Tracer, io.Closer, error) {
+	return opentracing.NoopTracer{}, ioutil.NopCloser(nil), nil
+}
+
+// fakeSpanStore never has the span a handleEndEvent lookup asks for,
+// so every end event it sees turns into traces.SpanMissingError.
+type fakeSpanStore struct{}
+
+func (fakeSpanStore) Get(ctx context.Context, tracer opentracing.Tracer, id string) (opentracing.Span, error) {
+	return nil, nil
+}
+func (fakeSpanStore) Set(ctx context.Context, id string, span opentracing.Span) error { return nil }
+func (fakeSpanStore) Delete(ctx context.Context, id string) error                     { return nil }
+
+type fakeAck struct {
+	acked  bool
+	nacked bool
+}
+
+func (a *fakeAck) Ack() error { a.acked = true; return nil }
+func (a *fakeAck) Nack(retryAfter time.Duration) error {
+	a.nacked = true
+	return nil
+}
+
+type fakeDeadLetter struct {
+	called bool
+}
+
+func (d *fakeDeadLetter) Put(ctx context.Context, e queue.RawEvent, reason error) error {
+	d.called = true
+	return nil
+}
+
+func newTestPool(dl queue.DeadLetterSink) *WorkerPool {
+	wh := &Webhook{
+		EventSource: fakeEventSource{},
+		Tracers:     fakeTracers{},
+		Traces:      fakeSpanStore{},
+		Spans:       fakeSpanStore{},
+	}
+	return NewWorkerPool(wh, dl)
+}
+
+// TestProcess_SpanMissingError_RetriesPastMaxAttempts asserts the bug
+// the review flagged: an event far past DefaultMaxAttempts, but still
+// inside DefaultParentTTL, must keep being retried rather than
+// dead-lettered, since SpanMissingError retries are meant to be gated
+// solely on ParentTTL.
+func TestProcess_SpanMissingError_RetriesPastMaxAttempts(t *testing.T) {
+	dl := &fakeDeadLetter{}
+	p := newTestPool(dl)
+
+	e := queue.RawEvent{Attempts: DefaultMaxAttempts * 3, EnqueuedAt: time.Now().Add(-time.Minute)}
+	ack := &fakeAck{}
+
+	p.process(context.Background(), e, ack)
+
+	if dl.called || ack.acked {
+		t.Fatalf("expected event to be retried, not dead-lettered, within ParentTTL")
+	}
+	if !ack.nacked {
+		t.Fatalf("expected event to be nacked for redelivery")
+	}
+}
+
+// TestProcess_SpanMissingError_GivesUpPastParentTTL asserts the other
+// side of the same gate: once ParentTTL has elapsed, the event is
+// dead-lettered even on its very first attempt.
+func TestProcess_SpanMissingError_GivesUpPastParentTTL(t *testing.T) {
+	dl := &fakeDeadLetter{}
+	p := newTestPool(dl)
+
+	e := queue.RawEvent{Attempts: 0, EnqueuedAt: time.Now().Add(-(DefaultParentTTL + time.Minute))}
+	ack := &fakeAck{}
+
+	p.process(context.Background(), e, ack)
+
+	if !dl.called {
+		t.Fatalf("expected event past ParentTTL to be dead-lettered")
+	}
+	if !ack.acked {
+		t.Fatalf("expected giveUp to Ack the event so it leaves the queue")
+	}
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileDeadLetterSink appends every dead-lettered RawEvent to a
+// durable, append-only JSON-lines file, so an operator running WAL for
+// queue durability has somewhere to actually point WorkerPool.DeadLetter
+// rather than losing events a worker gave up on. It's intentionally
+// write-only at this layer (matching DeadLetterSink itself): replaying
+// or inspecting dead-lettered events means reading the file directly,
+// the same way WAL's own log is a plain JSON-lines file on disk.
+type FileDeadLetterSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// deadLetterRecord is one line of a FileDeadLetterSink's file.
+type deadLetterRecord struct {
+	Event      RawEvent  `json:"event"`
+	Reason     string    `json:"reason"`
+	DeadLetter time.Time `json:"dead_lettered_at"`
+}
+
+// NewFileDeadLetterSink opens (creating if necessary) the dead-letter
+// file at path, appending to anything already there from a prior run.
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening dead-letter file at %q: %w", path, err)
+	}
+
+	return &FileDeadLetterSink{
+		file: f,
+		enc:  json.NewEncoder(f),
+	}, nil
+}
+
+func (s *FileDeadLetterSink) Put(ctx context.Context, e RawEvent, reason error) error {
+	rec := deadLetterRecord{Event: e, DeadLetter: time.Now()}
+	if reason != nil {
+		rec.Reason = reason.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.enc.Encode(rec); err != nil {
+		return fmt.Errorf("appending dead-letter record: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileDeadLetterSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process EventQueue backed by a buffered channel. It
+// has no durability across restarts and is intended for local
+// development, tests, and single-process deployments where that
+// tradeoff is acceptable; use WAL for anything that needs to survive a
+// process restart.
+type Memory struct {
+	ch chan RawEvent
+
+	mu    sync.Mutex
+	depth int
+}
+
+// NewMemory returns a Memory queue with room for size in-flight events
+// before Enqueue starts blocking.
+func NewMemory(size int) *Memory {
+	return &Memory{
+		ch: make(chan RawEvent, size),
+	}
+}
+
+func (m *Memory) Enqueue(ctx context.Context, e RawEvent) error {
+	m.mu.Lock()
+	m.depth++
+	m.mu.Unlock()
+
+	select {
+	case m.ch <- e:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		m.depth--
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (m *Memory) Dequeue(ctx context.Context) (RawEvent, Ack, error) {
+	select {
+	case e := <-m.ch:
+		m.mu.Lock()
+		m.depth--
+		m.mu.Unlock()
+		return e, &memoryAck{q: m, e: e}, nil
+	case <-ctx.Done():
+		return RawEvent{}, nil, ctx.Err()
+	}
+}
+
+func (m *Memory) Depth() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.depth
+}
+
+type memoryAck struct {
+	q *Memory
+	e RawEvent
+}
+
+func (a *memoryAck) Ack() error {
+	return nil
+}
+
+func (a *memoryAck) Nack(retryAfter time.Duration) error {
+	e := a.e
+	e.Attempts++
+
+	if retryAfter <= 0 {
+		return a.q.Enqueue(context.Background(), e)
+	}
+
+	time.AfterFunc(retryAfter, func() {
+		// Best effort: a background context means a full queue blocks
+		// the timer goroutine rather than dropping the event.
+		a.q.Enqueue(context.Background(), e)
+	})
+	return nil
+}
@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	depthGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "valuestream_queue_depth",
+			Help: "Number of events currently waiting in an EventQueue.",
+		},
+		[]string{"queue"},
+	)
+
+	lagHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "valuestream_queue_lag_seconds",
+			Help:    "Time between an event being enqueued and a worker dequeuing it.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"queue"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(depthGauge, lagHistogram)
+}
+
+// Instrumented wraps an EventQueue so its depth and per-event lag are
+// scraped by Prometheus under name (e.g. "webhooks", "dead-letter").
+type Instrumented struct {
+	EventQueue
+	name string
+}
+
+// NewInstrumented wraps q, registering its depth/lag under name.
+func NewInstrumented(name string, q EventQueue) *Instrumented {
+	return &Instrumented{EventQueue: q, name: name}
+}
+
+func (i *Instrumented) Enqueue(ctx context.Context, e RawEvent) error {
+	err := i.EventQueue.Enqueue(ctx, e)
+	depthGauge.WithLabelValues(i.name).Set(float64(i.EventQueue.Depth()))
+	return err
+}
+
+func (i *Instrumented) Dequeue(ctx context.Context) (RawEvent, Ack, error) {
+	e, ack, err := i.EventQueue.Dequeue(ctx)
+	if err == nil {
+		lagHistogram.WithLabelValues(i.name).Observe(time.Since(e.EnqueuedAt).Seconds())
+	}
+	depthGauge.WithLabelValues(i.name).Set(float64(i.EventQueue.Depth()))
+	return e, ack, err
+}
@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// RawEvent is the durable unit of work an EventQueue carries between a
+// webhook receiver and the worker pool that processes it. It holds
+// everything a worker needs to replay eventsources.EventSource.Event on
+// its own schedule, without keeping the original *http.Request (and its
+// connection) alive.
+type RawEvent struct {
+	// Source identifies which eventsources.EventSource produced this
+	// event; webhooks.Webhook.Handler records the EventSource's Go type
+	// name, e.g. "*cloudevents.EventSource".
+	Source string
+
+	// Header holds the originating request's headers (all of them, as
+	// Webhook.Handler records them), which the EventSource needs to
+	// re-parse the payload (content-type, trace headers, ...).
+	Header map[string][]string
+
+	Payload []byte
+
+	// Attempts is the number of times this event has been returned to
+	// the queue via Nack, i.e. its earlier failed deliveries; it is 0
+	// when a worker first receives the event.
+	Attempts int
+
+	EnqueuedAt time.Time
+}
+
+// EventQueue is the durable hand-off point between a webhook's receiver
+// stage and its worker pool. Splitting receipt from processing this way
+// means a SpanStore hiccup no longer costs us a delivery: the receiver
+// can 202 as soon as the event is durably queued, and a worker retries
+// independently of the source's own retry/backoff policy.
+//
+// Implementations: Memory (dev/tests) and a disk-backed WAL for
+// single-node durability across restarts. A multi-node deployment
+// wanting a shared queue (NATS/Kafka/Watermill, ...) implements this
+// interface itself; neither of the two adapters shipped here involves
+// another process.
+type EventQueue interface {
+	Enqueue(ctx context.Context, e RawEvent) error
+
+	// Dequeue blocks until an event is available or ctx is cancelled.
+	// The caller must call exactly one of the returned Ack's Ack or
+	// Nack methods once it has finished (or given up on) the event.
+	Dequeue(ctx context.Context) (RawEvent, Ack, error)
+
+	// Depth reports the number of events currently waiting to be
+	// dequeued, for the `valuestream_queue_depth` gauge.
+	Depth() int
+}
+
+// Ack resolves a previously dequeued RawEvent.
+type Ack interface {
+	// Ack marks the event as successfully processed and permanently
+	// removes it from the queue.
+	Ack() error
+
+	// Nack returns the event to the queue to be redelivered no sooner
+	// than retryAfter from now. Queues use this for the exponential
+	// backoff a worker applies after a transient failure (e.g.
+	// traces.SpanMissingError while the parent webhook is still in
+	// flight).
+	Nack(retryAfter time.Duration) error
+}
+
+// DeadLetterSink receives events a worker has given up on: because
+// they exceeded MaxAttempts, because their parent span never showed up
+// within the configured TTL, or because their payload could not be
+// converted to an event at all.
+//
+// Implementations: FileDeadLetterSink, a disk-backed sink reusing WAL's
+// append-only JSON-lines pattern.
+type DeadLetterSink interface {
+	Put(ctx context.Context, e RawEvent, reason error) error
+}
@@ -0,0 +1,160 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryEnqueueDequeueRoundTrip(t *testing.T) {
+	m := NewMemory(1)
+	ctx := context.Background()
+
+	want := RawEvent{Source: "github", Payload: []byte("payload"), EnqueuedAt: time.Now()}
+	if err := m.Enqueue(ctx, want); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if depth := m.Depth(); depth != 1 {
+		t.Fatalf("Depth() = %d, want 1", depth)
+	}
+
+	got, ack, err := m.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if got.Source != want.Source || string(got.Payload) != string(want.Payload) {
+		t.Fatalf("Dequeue() = %+v, want %+v", got, want)
+	}
+	if depth := m.Depth(); depth != 0 {
+		t.Fatalf("Depth() after Dequeue = %d, want 0", depth)
+	}
+
+	if err := ack.Ack(); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+}
+
+func TestWALReplaysUnackedEvents(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/wal.log"
+
+	w, err := NewWAL(path, 4)
+	if err != nil {
+		t.Fatalf("NewWAL: %v", err)
+	}
+
+	ctx := context.Background()
+	acked := RawEvent{Source: "github", Payload: []byte("acked"), EnqueuedAt: time.Now()}
+	pending := RawEvent{Source: "github", Payload: []byte("pending"), EnqueuedAt: time.Now().Add(time.Second)}
+
+	if err := w.Enqueue(ctx, acked); err != nil {
+		t.Fatalf("Enqueue(acked): %v", err)
+	}
+	if err := w.Enqueue(ctx, pending); err != nil {
+		t.Fatalf("Enqueue(pending): %v", err)
+	}
+
+	// Drain and ack only the first event, leaving the second in-flight
+	// when the WAL "restarts".
+	got, ack, err := w.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if string(got.Payload) != string(acked.Payload) {
+		t.Fatalf("Dequeue() = %+v, want acked event first", got)
+	}
+	if err := ack.Ack(); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	w.Close()
+
+	w2, err := NewWAL(path, 4)
+	if err != nil {
+		t.Fatalf("NewWAL (replay): %v", err)
+	}
+	defer w2.Close()
+
+	if depth := w2.Depth(); depth != 1 {
+		t.Fatalf("Depth() after replay = %d, want 1 (only the unacked event)", depth)
+	}
+
+	replayed, _, err := w2.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue (replay): %v", err)
+	}
+	if string(replayed.Payload) != string(pending.Payload) {
+		t.Fatalf("replayed event = %+v, want %+v", replayed, pending)
+	}
+}
+
+func TestWalIDStableForSameEvent(t *testing.T) {
+	e := RawEvent{Source: "github", Payload: []byte("same payload"), EnqueuedAt: time.Unix(0, 123)}
+	if walID(e) != walID(e) {
+		t.Fatalf("walID is not stable for the same RawEvent")
+	}
+
+	other := e
+	other.Payload = []byte("different payload")
+	if walID(e) == walID(other) {
+		t.Fatalf("walID collided for RawEvents with different payloads")
+	}
+}
+
+func TestFileDeadLetterSinkAppendsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/deadletter.log"
+
+	s, err := NewFileDeadLetterSink(path)
+	if err != nil {
+		t.Fatalf("NewFileDeadLetterSink: %v", err)
+	}
+
+	ctx := context.Background()
+	first := RawEvent{Source: "github", Payload: []byte("first"), EnqueuedAt: time.Now()}
+	if err := s.Put(ctx, first, fmt.Errorf("span missing")); err != nil {
+		t.Fatalf("Put(first): %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s2, err := NewFileDeadLetterSink(path)
+	if err != nil {
+		t.Fatalf("NewFileDeadLetterSink (reopen): %v", err)
+	}
+	defer s2.Close()
+
+	second := RawEvent{Source: "github", Payload: []byte("second"), EnqueuedAt: time.Now()}
+	if err := s2.Put(ctx, second, nil); err != nil {
+		t.Fatalf("Put(second): %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d dead-letter records, want 2 (one from each sink instance)", len(lines))
+	}
+
+	var rec1, rec2 deadLetterRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec1); err != nil {
+		t.Fatalf("unmarshalling first record: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &rec2); err != nil {
+		t.Fatalf("unmarshalling second record: %v", err)
+	}
+
+	if string(rec1.Event.Payload) != "first" || rec1.Reason != "span missing" {
+		t.Fatalf("first record = %+v, want payload %q reason %q", rec1, "first", "span missing")
+	}
+	if string(rec2.Event.Payload) != "second" || rec2.Reason != "" {
+		t.Fatalf("second record = %+v, want payload %q no reason", rec2, "second")
+	}
+}
@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// WAL is a disk-backed EventQueue: every Enqueue is appended to a
+// write-ahead log before being handed to an in-memory Memory queue for
+// dispatch, and every Ack appends a matching tombstone. On startup,
+// NewWAL replays the log and re-enqueues anything that was never
+// acked, so an in-flight event survives a worker process restart.
+//
+// The log is append-only and is not compacted; operators should rotate
+// it out from under a stopped process once it's been replayed, or run
+// NewWAL against a fresh path after a planned restart.
+type WAL struct {
+	mem *Memory
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+type walRecord struct {
+	Op    string   `json:"op"` // "enqueue" or "ack"
+	ID    string   `json:"id"`
+	Event RawEvent `json:"event,omitempty"`
+}
+
+// NewWAL opens (and if necessary replays) the WAL at path. size is the
+// in-memory dispatch buffer, as in NewMemory.
+func NewWAL(path string, size int) (*WAL, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening WAL at %q: %w", path, err)
+	}
+
+	w := &WAL{
+		mem:  NewMemory(size),
+		file: f,
+		enc:  json.NewEncoder(f),
+	}
+
+	if err := w.replay(path); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *WAL) replay(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening WAL for replay at %q: %w", path, err)
+	}
+	defer f.Close()
+
+	pending := make(map[string]RawEvent)
+
+	scanner := bufio.NewScanner(f)
+	// Event payloads can be sizeable (full webhook bodies); grow past
+	// bufio's 64KB default line limit.
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec walRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("corrupt WAL record: %w", err)
+		}
+
+		switch rec.Op {
+		case "enqueue":
+			pending[rec.ID] = rec.Event
+		case "ack":
+			delete(pending, rec.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading WAL: %w", err)
+	}
+
+	for _, e := range pending {
+		if err := w.mem.Enqueue(context.Background(), e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *WAL) Enqueue(ctx context.Context, e RawEvent) error {
+	id := walID(e)
+
+	w.mu.Lock()
+	err := w.enc.Encode(walRecord{Op: "enqueue", ID: id, Event: e})
+	w.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("appending WAL enqueue record: %w", err)
+	}
+
+	return w.mem.Enqueue(ctx, e)
+}
+
+func (w *WAL) Dequeue(ctx context.Context) (RawEvent, Ack, error) {
+	e, ack, err := w.mem.Dequeue(ctx)
+	if err != nil {
+		return e, ack, err
+	}
+
+	return e, &walAck{w: w, id: walID(e), inner: ack}, nil
+}
+
+func (w *WAL) Depth() int {
+	return w.mem.Depth()
+}
+
+func (w *WAL) Close() error {
+	return w.file.Close()
+}
+
+// walID derives a stable identifier for a RawEvent so its eventual ack
+// can be matched back to the enqueue record during replay. Events don't
+// otherwise carry an identifier of their own at this layer.
+func walID(e RawEvent) string {
+	return fmt.Sprintf("%s-%d-%x", e.Source, e.EnqueuedAt.UnixNano(), e.Payload[:min(len(e.Payload), 16)])
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type walAck struct {
+	w     *WAL
+	id    string
+	inner Ack
+}
+
+func (a *walAck) Ack() error {
+	a.w.mu.Lock()
+	err := a.w.enc.Encode(walRecord{Op: "ack", ID: a.id})
+	a.w.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("appending WAL ack record: %w", err)
+	}
+	return a.inner.Ack()
+}
+
+func (a *walAck) Nack(retryAfter time.Duration) error {
+	return a.inner.Nack(retryAfter)
+}
@@ -0,0 +1,26 @@
+package sampling
+
+import "github.com/opentracing/opentracing-go"
+
+// sampledSpanContext is satisfied by tracer implementations (e.g.
+// jaeger.SpanContext) that expose their own sampling decision, which
+// opentracing.SpanContext itself has no notion of.
+type sampledSpanContext interface {
+	IsSampled() bool
+}
+
+// ParentSampled reports the upstream trace's sampling decision for sc,
+// or nil when sc is nil or the tracer's SpanContext doesn't expose one.
+func ParentSampled(sc opentracing.SpanContext) *bool {
+	if sc == nil {
+		return nil
+	}
+
+	s, ok := sc.(sampledSpanContext)
+	if !ok {
+		return nil
+	}
+
+	sampled := s.IsSampled()
+	return &sampled
+}
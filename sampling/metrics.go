@@ -0,0 +1,47 @@
+package sampling
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+var decisionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "valuestream_sampling_decisions_total",
+		Help: "Count of span sampling decisions by event source and outcome.",
+	},
+	[]string{"source", "decision"},
+)
+
+func init() {
+	prometheus.MustRegister(decisionsTotal)
+}
+
+// Instrumented wraps a Policy, recording every decision it makes under
+// valuestream_sampling_decisions_total{source, decision="accepted"|"dropped"}.
+type Instrumented struct {
+	Policy
+}
+
+// NewInstrumented wraps p with Prometheus accepted/dropped counters.
+func NewInstrumented(p Policy) Instrumented {
+	return Instrumented{Policy: p}
+}
+
+func (i Instrumented) Sample(ctx context.Context, source string, parentSampled *bool, e eventsources.Event) (Decision, error) {
+	d, err := i.Policy.Sample(ctx, source, parentSampled, e)
+	if err != nil {
+		return d, err
+	}
+
+	outcome := "dropped"
+	if d.Sample {
+		outcome = "accepted"
+	}
+	decisionsTotal.WithLabelValues(source, outcome).Inc()
+
+	return d, nil
+}
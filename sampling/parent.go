@@ -0,0 +1,22 @@
+package sampling
+
+import (
+	"context"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// ParentBased honours the parent's own sampling decision (see Policy
+// and ParentSampled), whether that parent is a span already in the
+// SpanStore or a context extracted via webhooks.ExtractSpanContext,
+// and falls back to Local when there is no decision to honour.
+type ParentBased struct {
+	Local Policy
+}
+
+func (pb ParentBased) Sample(ctx context.Context, source string, parentSampled *bool, e eventsources.Event) (Decision, error) {
+	if parentSampled != nil {
+		return Decision{Sample: *parentSampled, Reason: "parent-based"}, nil
+	}
+	return pb.Local.Sample(ctx, source, parentSampled, e)
+}
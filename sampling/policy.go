@@ -0,0 +1,31 @@
+// Package sampling decides, per start event, whether Valuestream
+// should actually create and store a span. Sampling everything is
+// wasteful for very high-volume sources (CI matrix builds, monorepo
+// PRs) and buries the traces that matter in noise from the ones that
+// don't.
+package sampling
+
+import (
+	"context"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// Decision is a Policy's verdict on a single candidate span, along
+// with the reason it was made (surfaced in logs/metrics, not used for
+// control flow).
+type Decision struct {
+	Sample bool
+	Reason string
+}
+
+// Policy is consulted by Webhook.handleStartEvent before starting a
+// span. parentSampled is the parent SpanContext's own sampling
+// decision: that of the parent span found in the SpanStore when there
+// is one, otherwise that of a context extracted from the request's
+// trace headers (see webhooks.ExtractSpanContext). It is nil when there
+// is no parent at all or the parent's SpanContext exposes no decision
+// (see ParentSampled).
+type Policy interface {
+	Sample(ctx context.Context, source string, parentSampled *bool, e eventsources.Event) (Decision, error)
+}
@@ -0,0 +1,23 @@
+package sampling
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// Probabilistic samples a fixed fraction of events, irrespective of
+// source or tags - the simplest way to cut volume from a very
+// high-throughput source.
+type Probabilistic struct {
+	// Rate is the fraction of events to sample, in [0, 1].
+	Rate float64
+}
+
+func (p Probabilistic) Sample(ctx context.Context, source string, parentSampled *bool, e eventsources.Event) (Decision, error) {
+	if rand.Float64() < p.Rate {
+		return Decision{Sample: true, Reason: "probabilistic"}, nil
+	}
+	return Decision{Sample: false, Reason: "probabilistic"}, nil
+}
@@ -0,0 +1,50 @@
+package sampling
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// RateLimited caps how many spans per second a single event source can
+// produce, via an independent leaky bucket per source, so one noisy
+// source can't crowd out the rest.
+type RateLimited struct {
+	Rate  float64
+	Burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRateLimited returns a RateLimited policy allowing r events/sec
+// (with bursts up to burst) for each distinct source it sees.
+func NewRateLimited(r float64, burst int) *RateLimited {
+	return &RateLimited{
+		Rate:     r,
+		Burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+func (rl *RateLimited) limiter(source string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	l, ok := rl.limiters[source]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(rl.Rate), rl.Burst)
+		rl.limiters[source] = l
+	}
+	return l
+}
+
+func (rl *RateLimited) Sample(ctx context.Context, source string, parentSampled *bool, e eventsources.Event) (Decision, error) {
+	if rl.limiter(source).Allow() {
+		return Decision{Sample: true, Reason: "rate-limited"}, nil
+	}
+	return Decision{Sample: false, Reason: "rate-limited"}, nil
+}
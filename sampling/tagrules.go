@@ -0,0 +1,68 @@
+package sampling
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// TagRule is a single always-sample/always-drop predicate matched
+// against one of an event's tags, e.g. {Tag: "branch", Pattern:
+// "dependabot/*", Sample: false}.
+type TagRule struct {
+	Tag     string
+	Pattern string // see matchPattern
+	Sample  bool
+}
+
+// matchPattern reports whether v matches pattern. A pattern ending in
+// "/*" is matched as a plain prefix, so it also matches across further
+// "/"-separated segments (e.g. "dependabot/*" matches
+// "dependabot/npm_and_yarn/lodash-4.17.21") - real Dependabot branch
+// names always have at least two segments after the prefix, and
+// path.Match's "*" never crosses a "/". Any other pattern is matched
+// with path.Match.
+func matchPattern(pattern, v string) (bool, error) {
+	if prefix := strings.TrimSuffix(pattern, "*"); strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(v, prefix), nil
+	}
+	return path.Match(pattern, v)
+}
+
+// TagRules evaluates Rules in order and returns the first match's
+// verdict, falling through to Fallback (if set) when nothing matches.
+type TagRules struct {
+	Rules    []TagRule
+	Fallback Policy
+}
+
+func (tr TagRules) Sample(ctx context.Context, source string, parentSampled *bool, e eventsources.Event) (Decision, error) {
+	tags, err := e.Tags()
+	if err != nil {
+		return Decision{}, err
+	}
+
+	for _, rule := range tr.Rules {
+		v, ok := tags[rule.Tag]
+		if !ok {
+			continue
+		}
+
+		matched, err := matchPattern(rule.Pattern, v)
+		if err != nil {
+			return Decision{}, err
+		}
+
+		if matched {
+			return Decision{Sample: rule.Sample, Reason: "tag-rule:" + rule.Tag}, nil
+		}
+	}
+
+	if tr.Fallback != nil {
+		return tr.Fallback.Sample(ctx, source, parentSampled, e)
+	}
+
+	return Decision{Sample: true, Reason: "tag-rule-fallthrough"}, nil
+}
@@ -0,0 +1,33 @@
+package sampling
+
+import "testing"
+
+// TestMatchPattern pins the dependabot-style multi-segment case that
+// path.Match alone gets wrong: its "*" never crosses a "/", so
+// "dependabot/*" would never match a real Dependabot branch name,
+// which always has at least two segments after the prefix.
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"dependabot/*", "dependabot/npm_and_yarn/lodash-4.17.21", true},
+		{"dependabot/*", "dependabot/go_modules/golang.org/x/net-0.1.0", true},
+		{"dependabot/*", "feature/dependabot-config", false},
+		{"main", "main", true},
+		{"main", "not-main", false},
+		{"release-*", "release-1.2", true},
+		{"release-*", "release/1.2", false}, // single-segment glob still doesn't cross "/"
+	}
+
+	for _, c := range cases {
+		got, err := matchPattern(c.pattern, c.value)
+		if err != nil {
+			t.Fatalf("matchPattern(%q, %q) returned error: %v", c.pattern, c.value, err)
+		}
+		if got != c.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", c.pattern, c.value, got, c.want)
+		}
+	}
+}
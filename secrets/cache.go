@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// cacheEntry is one tenant's most recently resolved secrets, and when
+// they were fetched.
+type cacheEntry struct {
+	keys      [][]byte
+	fetchedAt time.Time
+}
+
+// Cached wraps a Resolver - typically Vault or AWSSecretsManager, both
+// of which make a network round trip on every Resolve - with a
+// short-TTL, per-tenant cache, so a high-volume source doesn't turn
+// every webhook delivery into a call to the backing secret store.
+//
+// A cache hit can still be wrong for up to TTL after a rotation;
+// Invalidate lets a caller force the next Resolve for a tenant past
+// the cache, which Webhook does whenever a delivery's signature fails
+// to match any of the resolved candidates (see Invalidator).
+type Cached struct {
+	Resolver Resolver
+	KeyFunc  TenantKeyFunc
+	TTL      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCached returns a Resolver caching r's results per tenant key (as
+// produced by keyFunc) for ttl.
+func NewCached(r Resolver, keyFunc TenantKeyFunc, ttl time.Duration) *Cached {
+	return &Cached{
+		Resolver: r,
+		KeyFunc:  keyFunc,
+		TTL:      ttl,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+func (c *Cached) Resolve(ctx context.Context, r *http.Request, es eventsources.EventSource) ([][]byte, error) {
+	key, err := c.KeyFunc(r, es)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && time.Since(entry.fetchedAt) < c.TTL {
+		return entry.keys, nil
+	}
+
+	keys, err := c.Resolver.Resolve(ctx, r, es)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{keys: keys, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return keys, nil
+}
+
+// Invalidate forces the next Resolve for the tenant r/es resolve to
+// (via KeyFunc) to hit the backing Resolver even if TTL hasn't elapsed
+// yet.
+func (c *Cached) Invalidate(ctx context.Context, r *http.Request, es eventsources.EventSource) error {
+	key, err := c.KeyFunc(r, es)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+
+	return nil
+}
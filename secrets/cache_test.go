@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+type fakeEventSource struct{}
+
+func (fakeEventSource) ValidatePayload(r *http.Request, candidates [][]byte) ([]byte, []byte, error) {
+	return nil, nil, nil
+}
+
+func (fakeEventSource) Event(r *http.Request, payload []byte) (eventsources.Event, error) {
+	return nil, nil
+}
+
+type countingResolver struct {
+	calls int
+	keys  [][]byte
+}
+
+func (c *countingResolver) Resolve(ctx context.Context, r *http.Request, es eventsources.EventSource) ([][]byte, error) {
+	c.calls++
+	return c.keys, nil
+}
+
+func staticKeyFunc(r *http.Request, es eventsources.EventSource) (string, error) {
+	return "tenant", nil
+}
+
+func TestCachedResolveHitsBackingResolverOnce(t *testing.T) {
+	inner := &countingResolver{keys: [][]byte{[]byte("sk")}}
+	c := NewCached(inner, staticKeyFunc, time.Minute)
+
+	r := &http.Request{}
+	for i := 0; i < 3; i++ {
+		if _, err := c.Resolve(context.Background(), r, fakeEventSource{}); err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+	}
+
+	if inner.calls != 1 {
+		t.Fatalf("backing Resolver called %d times, want 1 within TTL", inner.calls)
+	}
+}
+
+func TestCachedResolveRefetchesAfterTTL(t *testing.T) {
+	inner := &countingResolver{keys: [][]byte{[]byte("sk")}}
+	c := NewCached(inner, staticKeyFunc, time.Millisecond)
+
+	r := &http.Request{}
+	if _, err := c.Resolve(context.Background(), r, fakeEventSource{}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := c.Resolve(context.Background(), r, fakeEventSource{}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if inner.calls != 2 {
+		t.Fatalf("backing Resolver called %d times, want 2 after TTL elapsed", inner.calls)
+	}
+}
+
+func TestCachedInvalidateForcesRefetch(t *testing.T) {
+	inner := &countingResolver{keys: [][]byte{[]byte("sk")}}
+	c := NewCached(inner, staticKeyFunc, time.Hour)
+
+	r := &http.Request{}
+	if _, err := c.Resolve(context.Background(), r, fakeEventSource{}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if err := c.Invalidate(context.Background(), r, fakeEventSource{}); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if _, err := c.Resolve(context.Background(), r, fakeEventSource{}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if inner.calls != 2 {
+		t.Fatalf("backing Resolver called %d times, want 2 (initial + post-invalidate)", inner.calls)
+	}
+}
@@ -0,0 +1,135 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// fileFormat is the on-disk shape of a File resolver's YAML:
+//
+//   tenants:
+//     myorg/myrepo:
+//       - "s3cr3t-current"
+//       - "s3cr3t-previous"
+type fileFormat struct {
+	Tenants map[string][]string `yaml:"tenants"`
+}
+
+// File resolves secrets from a YAML file, re-reading it whenever it
+// changes on disk so an operator (or a config-management tool writing
+// the file from Vault/SSM themselves) can rotate a tenant's secret
+// without restarting Valuestream.
+type File struct {
+	path    string
+	keyFunc TenantKeyFunc
+	watcher *fsnotify.Watcher
+
+	mu      sync.RWMutex
+	tenants map[string][][]byte
+}
+
+// NewFile returns a File resolver watching path, using keyFunc to map
+// a request to the tenant keys used in the YAML file.
+func NewFile(path string, keyFunc TenantKeyFunc) (*File, error) {
+	f := &File{path: path, keyFunc: keyFunc}
+
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating secrets file watcher: %w", err)
+	}
+	// Watch path's parent directory rather than path itself: a
+	// config-management tool (or editor) rotating the file almost
+	// always replaces it atomically via a temp-file-plus-rename, which
+	// drops an inotify watch held on the file's old inode. The
+	// directory's watch survives that; f.watch filters back down to
+	// just this file's events by name.
+	dir := filepath.Dir(path)
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("watching %q: %w", dir, err)
+	}
+	f.watcher = watcher
+
+	go f.watch()
+
+	return f, nil
+}
+
+func (f *File) watch() {
+	base := filepath.Base(f.path)
+	for event := range f.watcher.Events {
+		if filepath.Base(event.Name) != base {
+			continue
+		}
+		if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+			continue
+		}
+		if err := f.reload(); err != nil {
+			log.WithFields(log.Fields{"error": err.Error(), "path": f.path}).
+				Errorf("unable to reload secrets file")
+		}
+	}
+}
+
+func (f *File) reload() error {
+	raw, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("reading secrets file %q: %w", f.path, err)
+	}
+
+	var parsed fileFormat
+	if err := yaml.Unmarshal(raw, &parsed); err != nil {
+		return fmt.Errorf("parsing secrets file %q: %w", f.path, err)
+	}
+
+	tenants := make(map[string][][]byte, len(parsed.Tenants))
+	for tenant, sks := range parsed.Tenants {
+		bs := make([][]byte, len(sks))
+		for i, sk := range sks {
+			bs[i] = []byte(sk)
+		}
+		tenants[tenant] = bs
+	}
+
+	f.mu.Lock()
+	f.tenants = tenants
+	f.mu.Unlock()
+
+	return nil
+}
+
+func (f *File) Resolve(ctx context.Context, r *http.Request, es eventsources.EventSource) ([][]byte, error) {
+	key, err := f.keyFunc(r, es)
+	if err != nil {
+		return nil, fmt.Errorf("resolving tenant key: %w", err)
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	sks, ok := f.tenants[key]
+	if !ok {
+		return nil, fmt.Errorf("no secrets configured for tenant %q", key)
+	}
+
+	return sks, nil
+}
+
+// Close stops watching the underlying file.
+func (f *File) Close() error {
+	return f.watcher.Close()
+}
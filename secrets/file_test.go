@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+func writeTenantsFile(t *testing.T, path, secret string) {
+	t.Helper()
+	contents := "tenants:\n  myorg/myrepo:\n    - \"" + secret + "\"\n"
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("writing %q: %v", path, err)
+	}
+}
+
+func keyFunc(r *http.Request, es eventsources.EventSource) (string, error) {
+	return "myorg/myrepo", nil
+}
+
+// TestFileReloadsAcrossAtomicRename exercises the exact rotation
+// pattern a config-management tool uses: write the new contents to a
+// temp file in the same directory, then rename it over the watched
+// path. A watch held directly on the original path's inode would miss
+// this; watching the parent directory must not.
+func TestFileReloadsAcrossAtomicRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.yaml")
+
+	writeTenantsFile(t, path, "sk-before")
+
+	f, err := NewFile(path, keyFunc)
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	defer f.Close()
+
+	r := &http.Request{}
+	sks, err := f.Resolve(nil, r, nil)
+	if err != nil {
+		t.Fatalf("Resolve (initial): %v", err)
+	}
+	if string(sks[0]) != "sk-before" {
+		t.Fatalf("Resolve (initial) = %q, want sk-before", sks[0])
+	}
+
+	tmp := filepath.Join(dir, "secrets.yaml.tmp")
+	writeTenantsFile(t, tmp, "sk-after")
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("renaming %q over %q: %v", tmp, path, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		sks, err := f.Resolve(nil, r, nil)
+		if err != nil {
+			t.Fatalf("Resolve (after rename): %v", err)
+		}
+		if string(sks[0]) == "sk-after" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Resolve never picked up the rotated secret after an atomic rename")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,58 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// TenantKeyFunc extracts the tenant identifier (a GitHub "org/repo", a
+// Jira project key, a GitLab group path, ...) a request belongs to, so
+// Map can look up its secrets. What identifies a tenant is specific to
+// each EventSource, so this is left to the caller rather than guessed
+// from the payload here.
+type TenantKeyFunc func(r *http.Request, es eventsources.EventSource) (string, error)
+
+// Map resolves secrets from an in-memory map keyed by tenant, built at
+// startup from wherever the operator's secret store of choice is
+// (a config file, an internal API, ...). It has no built-in rotation
+// or hot-reload; see File for that.
+type Map struct {
+	mu      sync.RWMutex
+	secrets map[string][][]byte
+	keyFunc TenantKeyFunc
+}
+
+// NewMap returns a Map resolving the tenant key of a request with
+// keyFunc against secrets.
+func NewMap(keyFunc TenantKeyFunc, secrets map[string][][]byte) *Map {
+	return &Map{keyFunc: keyFunc, secrets: secrets}
+}
+
+func (m *Map) Resolve(ctx context.Context, r *http.Request, es eventsources.EventSource) ([][]byte, error) {
+	key, err := m.keyFunc(r, es)
+	if err != nil {
+		return nil, fmt.Errorf("resolving tenant key: %w", err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sks, ok := m.secrets[key]
+	if !ok {
+		return nil, fmt.Errorf("no secrets configured for tenant %q", key)
+	}
+
+	return sks, nil
+}
+
+// Set replaces the secrets registered for tenant key, e.g. to publish a
+// rotated secret alongside the outgoing one: m.Set(key, [][]byte{newSK, oldSK}).
+func (m *Map) Set(key string, sks [][]byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[key] = sks
+}
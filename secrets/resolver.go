@@ -0,0 +1,42 @@
+// Package secrets resolves the webhook signing secret(s) valid for a
+// given request, so one Valuestream instance can host many tenants
+// (GitHub orgs, Jira/GitLab projects, ...) each rotating their own
+// secret independently and without a restart.
+package secrets
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ImpactInsights/valuestream/eventsources"
+)
+
+// Resolver returns every secret currently valid for r. Implementations
+// return more than one value while a secret is mid-rotation: the new
+// value alongside the old one, so deliveries signed with either are
+// accepted until the old secret is retired.
+type Resolver interface {
+	Resolve(ctx context.Context, r *http.Request, es eventsources.EventSource) ([][]byte, error)
+}
+
+// Invalidator is implemented by Resolvers that cache their underlying
+// secret store lookups (see Cached). A Webhook that fails to validate
+// a delivery's signature against any resolved candidate calls
+// Invalidate before giving up, in case a rotation landed after the
+// cache's last fetch but inside its TTL.
+type Invalidator interface {
+	Invalidate(ctx context.Context, r *http.Request, es eventsources.EventSource) error
+}
+
+// Static always resolves to the same instance-wide secret, matching
+// Valuestream's original single-tenant behaviour.
+type Static [][]byte
+
+// NewStatic returns a Resolver for a single static secret.
+func NewStatic(sk []byte) Static {
+	return Static{sk}
+}
+
+func (s Static) Resolve(ctx context.Context, r *http.Request, es eventsources.EventSource) ([][]byte, error) {
+	return [][]byte(s), nil
+}